@@ -0,0 +1,84 @@
+package generation
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ch3nnn/sql2pb/cmd/generation/parser"
+)
+
+var CheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Reports breaking changes between an existing protobuf file and the database",
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := loadConfig(configFile)
+		if nil != err {
+			log.Fatal(err)
+		}
+		targets, err := conf.targets(target)
+		if nil != err {
+			log.Fatal(err)
+		}
+
+		var breaking bool
+		for _, options := range targets {
+			if err := applyOptions(cmd, options); nil != err {
+				log.Fatal(err)
+			}
+			changes, err := check(conf)
+			if nil != err {
+				log.Fatal(err)
+			}
+
+			for _, c := range changes {
+				fmt.Println(c)
+			}
+			breaking = breaking || len(changes) > 0
+		}
+		if breaking {
+			os.Exit(1)
+		}
+	},
+}
+
+// check returns the breaking changes between the protobuf file and the current options.
+func check(conf *Config) ([]parser.Change, error) {
+	if "" == protoFile {
+		return nil, fmt.Errorf("the protobuf file to check is required. --proto")
+	}
+
+	data, err := os.ReadFile(protoFile)
+	if nil != err {
+		return nil, err
+	}
+	previous := string(data)
+
+	s, err := generateSchema(conf, previous, table, ignoreTables, ignoreColumns, serviceName, fieldStyle)
+	if nil != err {
+		return nil, err
+	}
+
+	// the lock is only read, numbers of new fields are not kept
+	out, err := s.Render()
+	if nil != err {
+		return nil, err
+	}
+	// the custom definitions of a file kept up to date by gen --merge are not removed
+	if merge {
+		if out, err = parser.Merge(previous, out); nil != err {
+			return nil, errors.Wrapf(err, "merge %s", protoFile)
+		}
+	}
+
+	changes, err := parser.BreakingChanges(strings.NewReader(previous), strings.NewReader(out))
+	if nil != err {
+		return nil, errors.Wrapf(err, "check %s", protoFile)
+	}
+
+	return changes, nil
+}
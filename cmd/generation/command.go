@@ -3,8 +3,11 @@ package generation
 import (
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/spf13/cobra"
+
+	"github.com/ch3nnn/sql2pb/cmd/generation/parser"
 )
 
 var (
@@ -12,47 +15,182 @@ var (
 	host          string
 	user          string
 	password      string
-	schema        string
+	passwordFile  string
+	dsn           string
+	sslmode       string
+	tlsMode       string
+	charset       string
+	schemas       []string
 	dbname        string
 	serviceName   string
 	packageName   string
 	goPackageName string
 	ignoreTables  []string
 	ignoreColumns []string
+	ops           []string
+	trimPrefixes  []string
+	trimSuffixes  []string
 	fieldStyle    string
 	table         string
+	versionColumn string
+	configFile    string
+	templateFile  string
+	templateDir   string
+	naming        string
+	lang          string
+	catalogFile   string
+	lockFile      string
+	protoFile     string
+	target        string
+	outputFile    string
+	updateStyle   string
+	respStyle     string
+	pagination    string
+	orderBy       string
+	filterStyle   string
 	port          int
+	batch         bool
+	stream        bool
+	singularize   bool
+	schemaPrefix  bool
+	merge         bool
+	showDiff      bool
+	checkOutput   bool
 )
 
 var GenCmd = &cobra.Command{
 	Use:   "gen",
 	Short: "Generates protobuf",
 	Run: func(cmd *cobra.Command, args []string) {
-		s, err := generateSchema(table, ignoreTables, ignoreColumns, serviceName, fieldStyle, dbType)
+		conf, err := loadConfig(configFile)
+		if nil != err {
+			log.Fatal(err)
+		}
+		targets, err := conf.targets(target)
 		if nil != err {
 			log.Fatal(err)
 		}
 
-		if nil != s {
-			fmt.Println(s)
+		for _, options := range targets {
+			if err := applyOptions(cmd, options); nil != err {
+				log.Fatal(err)
+			}
+			if err := gen(conf); nil != err {
+				log.Fatal(err)
+			}
 		}
 	},
 }
 
+// gen generates the protobuf file of the current options.
+func gen(conf *Config) error {
+	if (merge || showDiff || checkOutput) && "" == outputFile {
+		return fmt.Errorf("the output file is required by --merge, --diff and --check. --output")
+	}
+
+	var previous string
+	if "" != outputFile {
+		var err error
+		if previous, err = readOutput(); nil != err {
+			return err
+		}
+	}
+
+	s, err := generateSchema(conf, previous, table, ignoreTables, ignoreColumns, serviceName, fieldStyle)
+	if nil != err {
+		return err
+	}
+
+	out, err := s.Render()
+	if nil != err {
+		return err
+	}
+	if merge {
+		if out, err = parser.Merge(previous, out); nil != err {
+			return err
+		}
+	}
+
+	// the output file and the lock are left untouched
+	if showDiff || checkOutput {
+		diff, err := diffOutput(previous, out+"\n")
+		if nil != err {
+			return err
+		}
+		if showDiff {
+			fmt.Print(diff)
+		}
+		if checkOutput && "" != diff {
+			return fmt.Errorf("%s is out of date, run sql2pb gen", outputFile)
+		}
+		return nil
+	}
+
+	if "" == outputFile {
+		fmt.Println(out)
+	} else if err := os.WriteFile(outputFile, []byte(out+"\n"), 0o644); nil != err {
+		return err
+	}
+
+	// the lock is only updated once the schema rendered
+	if nil != s.Lock && "" != lockFile {
+		return s.Lock.Save(lockFile)
+	}
+
+	return nil
+}
+
 func init() {
-	GenCmd.Flags().StringVarP(&dbType, "db_type", "", "mysql", "the database type. mysql | postgres")
-	GenCmd.Flags().StringVarP(&host, "host", "", "localhost", "the database host")
-	GenCmd.Flags().IntVarP(&port, "port", "", 3306, "the database port")
-	GenCmd.Flags().StringVarP(&user, "user", "", "root", "the database user")
-	GenCmd.Flags().StringVarP(&password, "password", "", "", "the database password")
-	GenCmd.Flags().StringVarP(&schema, "schema", "", "", "the database schema")
-	GenCmd.Flags().StringVarP(&dbname, "dbname", "", "", "the database name")
-	GenCmd.Flags().StringVarP(&table, "table", "", "", "the table schema. multiple tables ',' split. ")
-	GenCmd.Flags().StringVarP(&serviceName, "service_name", "", schema, "the protocol buffer package. defaults to the database schema.")
-	GenCmd.Flags().StringVarP(&packageName, "package", "", schema, "the protocol buffer package. defaults to the database schema.")
-	GenCmd.Flags().StringVarP(&goPackageName, "go_package", "", "", "the protocol buffer go_package. defaults to the database schema.")
-	GenCmd.Flags().StringSliceVarP(&ignoreTables, "ignore_tables", "", []string{}, "a comma spaced list of tables to ignore")
-	GenCmd.Flags().StringSliceVarP(&ignoreColumns, "ignore_columns", "", []string{}, "a comma spaced list of mysql columns to ignore")
-	GenCmd.Flags().StringVarP(&fieldStyle, "field_style", "", "sql_pb", "gen protobuf field style. sql_pb | sqlPb")
+	addFlags(GenCmd)
+	addFlags(CheckCmd)
+	GenCmd.Flags().StringVarP(&outputFile, "output", "", "", "the protobuf file written. empty prints it")
+	GenCmd.Flags().BoolVarP(&showDiff, "diff", "", false, "print the unified diff of the output file instead of writing it")
+	GenCmd.Flags().BoolVarP(&checkOutput, "check", "", false, "exit with a non-zero code if the output file is out of date instead of writing it")
+	GenCmd.Flags().BoolVarP(&merge, "merge", "", false, "merge into the output file, keeping its field numbers and custom messages, enums and rpc functions")
+	CheckCmd.Flags().BoolVarP(&merge, "merge", "", false, "compare to the protobuf file merged into as gen --merge does, so its custom definitions are kept")
+	CheckCmd.Flags().StringVarP(&protoFile, "proto", "", "", "the existing protobuf file compared to the database")
+}
 
+// addFlags binds the options shared by the gen and check commands.
+func addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&dbType, "db_type", "", "mysql", "the database type. mysql | postgres")
+	cmd.Flags().StringVarP(&host, "host", "", "localhost", "the database host")
+	cmd.Flags().IntVarP(&port, "port", "", 0, "the database port. defaults to 3306 for mysql and 5432 for postgres")
+	cmd.Flags().StringVarP(&user, "user", "", "root", "the database user")
+	cmd.Flags().StringVarP(&password, "password", "", "", "the database password. defaults to the password file or SQL2PB_PASSWORD")
+	cmd.Flags().StringVarP(&passwordFile, "password_file", "", "", "the file holding the database password")
+	cmd.Flags().StringVarP(&dsn, "dsn", "", "", "the database url overriding the connection flags, e.g. postgres://user@host:5432/db?sslmode=require. defaults to SQL2PB_DSN or DATABASE_URL without connection flag")
+	cmd.Flags().StringVarP(&sslmode, "sslmode", "", "disable", "the postgres sslmode. disable | require | verify-ca | verify-full")
+	cmd.Flags().StringVarP(&tlsMode, "tls", "", "", "the mysql tls mode. true | false | skip-verify | preferred")
+	cmd.Flags().StringVarP(&charset, "charset", "", "", "the mysql connection charset, e.g. utf8mb4")
+	cmd.Flags().StringSliceVarP(&schemas, "schema", "", []string{}, "a comma spaced list of postgres schemas. defaults to the current schema")
+	cmd.Flags().BoolVarP(&schemaPrefix, "schema_prefix", "", false, "prefix message and enum names with the schema name, e.g. for tables of several schemas sharing a name")
+	cmd.Flags().StringVarP(&dbname, "dbname", "", "", "the database name")
+	cmd.Flags().StringVarP(&table, "table", "", "", "the table schema. multiple tables ',' split. ")
+	cmd.Flags().StringVarP(&serviceName, "service_name", "", "", "the protocol buffer service name. defaults to the camel case database name.")
+	cmd.Flags().StringVarP(&packageName, "package", "", "", "the protocol buffer package. defaults to the database name.")
+	cmd.Flags().StringVarP(&goPackageName, "go_package", "", "", "the protocol buffer go_package. defaults to ./ followed by the package.")
+	cmd.Flags().StringSliceVarP(&ignoreTables, "ignore_tables", "", []string{}, "a comma spaced list of tables to ignore")
+	cmd.Flags().StringSliceVarP(&ignoreColumns, "ignore_columns", "", []string{}, "a comma spaced list of mysql columns to ignore")
+	cmd.Flags().StringVarP(&fieldStyle, "field_style", "", "sql_pb", "gen protobuf field style. sql_pb | sqlPb")
+	cmd.Flags().StringVarP(&versionColumn, "version_column", "", "", "the optimistic locking column required by update and delete requests. empty disables it")
+	cmd.Flags().StringVarP(&updateStyle, "update_style", "", "optional", "gen update request style. optional | field_mask")
+	cmd.Flags().StringVarP(&respStyle, "resp_style", "", "empty", "gen add and update response style. empty | id | entity")
+	cmd.Flags().StringVarP(&pagination, "pagination", "", "offset", "gen list request pagination. offset | cursor | aip158")
+	cmd.Flags().StringVarP(&orderBy, "order_by", "", "none", "gen list request ordering. none | string | message")
+	cmd.Flags().StringVarP(&filterStyle, "filter_style", "", "equal", "gen filter message style. equal | operator")
+	cmd.Flags().BoolVarP(&batch, "batch", "", false, "gen batch insert, update, delete and get rpc functions")
+	cmd.Flags().StringSliceVarP(&trimPrefixes, "trim_prefix", "", []string{}, "a comma spaced list of table name prefixes trimmed from message names")
+	cmd.Flags().StringSliceVarP(&trimSuffixes, "trim_suffix", "", []string{}, "a comma spaced list of table name suffixes trimmed from message names")
+	cmd.Flags().StringSliceVarP(&ops, "ops", "", parser.Ops, "a comma spaced list of rpc operations to gen, empty gens none. create | update | delete | get | list")
+	cmd.Flags().StringVarP(&configFile, "config", "", "", "the config file setting the options, per table and per target. defaults to sql2pb.yaml if it exists")
+	cmd.Flags().StringVarP(&target, "target", "", "", "the config file target to gen. empty gens every target")
+	cmd.Flags().StringVarP(&templateFile, "template", "", "", "the text/template file rendering the protobuf file")
+	cmd.Flags().StringVarP(&templateDir, "template_dir", "", "", "the directory of *.tpl files overriding the default templates")
+	cmd.Flags().StringVarP(&naming, "naming", "", "goctl", "gen rpc and message naming. goctl | aip, names are customized in the config file")
+	cmd.Flags().StringVarP(&lang, "lang", "", "zh", "the language of generated comments. zh | en")
+	cmd.Flags().StringVarP(&catalogFile, "catalog", "", "", "the yaml file overriding the generated comment texts by message id")
+	cmd.Flags().StringVarP(&lockFile, "lock_file", "", "", "the json file keeping field numbers across regenerations, e.g. sql2pb.lock.json")
+	cmd.Flags().BoolVarP(&singularize, "singularize", "", false, "gen message names from the singular table name, the plural is used by the %p naming, e.g. List%p of aip")
+	cmd.Flags().BoolVarP(&stream, "stream", "", false, "gen server streaming export rpc functions")
 }
@@ -0,0 +1,255 @@
+package generation
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ch3nnn/sql2pb/cmd/generation/parser"
+)
+
+// defaultConfigFile is the configuration file read when --config is not set.
+const defaultConfigFile = "sql2pb.yaml"
+
+// Config represents the sql2pb configuration file.
+type Config struct {
+	// Options sets the command flags by name, e.g. host: localhost. the command line overrides them
+	Options map[string]interface{} `yaml:"options"`
+	// Naming overrides the rpc and message names of the --naming scheme
+	Naming parser.Naming `yaml:"naming"`
+	// Singulars overrides the singular of irregular table names with --singularize
+	Singulars map[string]string `yaml:"singulars"`
+	// Types overrides the protobuf type of a database data type, e.g. json: string
+	Types  map[string]string      `yaml:"types"`
+	Tables map[string]TableConfig `yaml:"tables"`
+	// Targets generates a protobuf file per target, e.g. per service
+	Targets []TargetConfig `yaml:"targets"`
+}
+
+// TableConfig represents the options of a single table.
+type TableConfig struct {
+	// Ops is the rpc operations to generate. create | update | delete | get | list
+	Ops []string `yaml:"ops"`
+	// IgnoreColumns is the columns of the table to ignore
+	IgnoreColumns []string `yaml:"ignore_columns"`
+	// Message overrides the message name of the table
+	Message string `yaml:"message"`
+	// Fields overrides the field names of the table columns by column name
+	Fields map[string]string `yaml:"fields"`
+}
+
+// TargetConfig represents a generated protobuf file.
+type TargetConfig struct {
+	// Name selects the target with --target
+	Name string `yaml:"name"`
+	// Options overrides the options of the configuration file, e.g. output: user.proto
+	Options map[string]interface{} `yaml:"options"`
+}
+
+// loadConfig reads the configuration file, an empty path reads sql2pb.yaml if it exists and
+// returns an empty configuration otherwise.
+func loadConfig(path string) (*Config, error) {
+	c := &Config{}
+	if path == "" {
+		if _, err := os.Stat(defaultConfigFile); nil != err {
+			return c, nil
+		}
+		path = defaultConfigFile
+	}
+
+	data, err := os.ReadFile(path)
+	if nil != err {
+		return nil, errors.Wrapf(err, "read config %s", path)
+	}
+	if err := yaml.Unmarshal(data, c); nil != err {
+		return nil, errors.Wrapf(err, "parse config %s", path)
+	}
+
+	return c, nil
+}
+
+// tableOps returns the rpc operations configured per table.
+func (c *Config) tableOps() map[string][]string {
+	tableOps := map[string][]string{}
+	for name, t := range c.Tables {
+		// an empty list gens no rpc function
+		if nil != t.Ops {
+			tableOps[name] = t.Ops
+		}
+	}
+
+	return tableOps
+}
+
+// tableIgnoreColumns returns the columns ignored per table.
+func (c *Config) tableIgnoreColumns() map[string][]string {
+	ignoreColumns := map[string][]string{}
+	for name, t := range c.Tables {
+		if len(t.IgnoreColumns) > 0 {
+			ignoreColumns[name] = t.IgnoreColumns
+		}
+	}
+
+	return ignoreColumns
+}
+
+// tableNames returns the message names configured per table.
+func (c *Config) tableNames() map[string]string {
+	names := map[string]string{}
+	for name, t := range c.Tables {
+		if "" != t.Message {
+			names[name] = t.Message
+		}
+	}
+
+	return names
+}
+
+// columnNames returns the field names configured per table and column.
+func (c *Config) columnNames() map[string]map[string]string {
+	names := map[string]map[string]string{}
+	for name, t := range c.Tables {
+		if len(t.Fields) > 0 {
+			names[name] = t.Fields
+		}
+	}
+
+	return names
+}
+
+// targets returns the options of every target selected by name, the options of the
+// configuration file when no target is configured. an empty name selects every target.
+func (c *Config) targets(name string) ([]map[string]interface{}, error) {
+	if len(c.Targets) == 0 {
+		if "" != name {
+			return nil, fmt.Errorf("target `%s` not found", name)
+		}
+		return []map[string]interface{}{c.Options}, nil
+	}
+
+	var targets []map[string]interface{}
+	for _, t := range c.Targets {
+		if "" != name && t.Name != name {
+			continue
+		}
+
+		options := map[string]interface{}{}
+		for k, v := range c.Options {
+			options[k] = v
+		}
+		for k, v := range t.Options {
+			options[k] = v
+		}
+		targets = append(targets, options)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("target `%s` not found", name)
+	}
+
+	return targets, nil
+}
+
+// applyOptions sets the flags of cmd not set on the command line to the options, or to their
+// default value. an option which is not a flag of any command is an error, e.g. output is only a
+// flag of gen. it records whether a connection is set by flags or options, see dataSourceURL.
+func applyOptions(cmd *cobra.Command, options map[string]interface{}) error {
+	for name := range options {
+		if !isOption(cmd.Root(), name) {
+			return fmt.Errorf("option `%s` not supported", name)
+		}
+	}
+
+	var err error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		// the configuration file and the targets are not options
+		if nil != err || f.Changed || "config" == f.Name || "target" == f.Name {
+			return
+		}
+
+		value, ok := options[f.Name]
+		if !ok {
+			value = f.DefValue
+			if _, ok := f.Value.(pflag.SliceValue); ok {
+				value = strings.Trim(f.DefValue, "[]")
+			}
+		}
+		if err = setFlag(f, value); nil != err {
+			err = errors.Wrapf(err, "option `%s`", f.Name)
+		}
+	})
+
+	connectionSet = map[string]bool{}
+	for _, name := range connectionFlags {
+		if _, ok := options[name]; ok || cmd.Flags().Changed(name) {
+			connectionSet[name] = true
+		}
+	}
+
+	return err
+}
+
+// isOption reports whether name is a flag of cmd or one of its sub commands.
+func isOption(cmd *cobra.Command, name string) bool {
+	if nil != cmd.Flags().Lookup(name) {
+		return true
+	}
+	for _, c := range cmd.Commands() {
+		if isOption(c, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setFlag sets a flag to an option value, a list sets a slice flag.
+func setFlag(f *pflag.Flag, value interface{}) error {
+	if _, ok := value.(map[string]interface{}); ok {
+		return fmt.Errorf("a map is not supported")
+	}
+
+	s, isSlice := f.Value.(pflag.SliceValue)
+	if list, ok := value.([]interface{}); ok {
+		if !isSlice {
+			return fmt.Errorf("a list is not supported")
+		}
+		values := []string{}
+		for _, v := range list {
+			values = append(values, fmt.Sprint(v))
+		}
+		return s.Replace(values)
+	}
+
+	if isSlice {
+		values := []string{}
+		if v := fmt.Sprint(value); "" != v {
+			values = strings.Split(v, ",")
+		}
+		return s.Replace(values)
+	}
+
+	return f.Value.Set(fmt.Sprint(value))
+}
+
+// loadCatalog reads the custom comment catalog, an empty path returns no catalog.
+func loadCatalog(path string) (parser.Catalog, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if nil != err {
+		return nil, errors.Wrapf(err, "read catalog %s", path)
+	}
+	c := parser.Catalog{}
+	if err := yaml.Unmarshal(data, &c); nil != err {
+		return nil, errors.Wrapf(err, "parse catalog %s", path)
+	}
+
+	return c, nil
+}
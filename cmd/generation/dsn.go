@@ -0,0 +1,138 @@
+package generation
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+// dsnEnvs are the environment variables read in order when neither --dsn nor a connection flag is set.
+var dsnEnvs = []string{"SQL2PB_DSN", "DATABASE_URL"}
+
+// connectionFlags are the flags and options of the connection overriding dsnEnvs.
+var connectionFlags = []string{"db_type", "host", "port", "user", "dbname", "sslmode", "tls", "charset"}
+
+// connectionSet holds the connection flags and options set, see applyOptions.
+var connectionSet map[string]bool
+
+// passwordEnv is the environment variable read when neither --password nor --password_file is set.
+const passwordEnv = "SQL2PB_PASSWORD"
+
+// dataSource returns the driver name and the data source name of the database connection. the
+// url form of --dsn, SQL2PB_DSN or DATABASE_URL takes precedence over the connection flags, e.g.
+// postgres://user@host:5432/db?sslmode=require. the password of the url is kept if set and
+// --sslmode, --tls and --charset set override its query parameters.
+func dataSource() (string, string, error) {
+	password, err := dbPassword()
+	if nil != err {
+		return "", "", err
+	}
+
+	source := dataSourceURL()
+	if "" == source {
+		switch dbType {
+		case "mysql":
+			cfg := mysql.NewConfig()
+			cfg.User = user
+			cfg.Passwd = password
+			cfg.Net = "tcp"
+			cfg.Addr = net.JoinHostPort(host, strconv.Itoa(port))
+			cfg.DBName = dbname
+			cfg.TLSConfig = tlsMode
+			if "" != charset {
+				cfg.Params = map[string]string{"charset": charset}
+			}
+			return dbType, cfg.FormatDSN(), nil
+		case "postgres":
+			u := &url.URL{
+				Scheme:   "postgres",
+				User:     url.UserPassword(user, password),
+				Host:     net.JoinHostPort(host, strconv.Itoa(port)),
+				Path:     "/" + dbname,
+				RawQuery: url.Values{"sslmode": {sslmode}}.Encode(),
+			}
+			return dbType, u.String(), nil
+		default:
+			return "", "", fmt.Errorf("db type `%s` not supported. mysql | postgres", dbType)
+		}
+	}
+
+	u, err := url.Parse(source)
+	if nil != err {
+		return "", "", errors.Wrap(err, "parse dsn")
+	}
+	if _, ok := u.User.Password(); !ok && "" != password {
+		u.User = url.UserPassword(u.User.Username(), password)
+	}
+
+	switch u.Scheme {
+	case "mysql":
+		setQuery(u, map[string]string{"tls": tlsMode, "charset": charset})
+		// the mysql driver reads its own format, user:password@tcp(host:port)/dbname?params
+		cfg, err := mysql.ParseDSN(fmt.Sprintf("tcp(%s)/%s?%s", u.Host, strings.TrimPrefix(u.Path, "/"), u.RawQuery))
+		if nil != err {
+			return "", "", errors.Wrap(err, "parse dsn")
+		}
+		cfg.User = u.User.Username()
+		cfg.Passwd, _ = u.User.Password()
+		dbType = "mysql"
+		return dbType, cfg.FormatDSN(), nil
+	case "postgres", "postgresql":
+		setQuery(u, map[string]string{"sslmode": sslmode})
+		dbType = "postgres"
+		return dbType, u.String(), nil
+	default:
+		return "", "", fmt.Errorf("dsn scheme `%s` not supported. mysql | postgres", u.Scheme)
+	}
+}
+
+// setQuery overrides the query parameters of a database url with the connection flags set.
+func setQuery(u *url.URL, params map[string]string) {
+	query := u.Query()
+	for name, value := range params {
+		if connectionSet[name] {
+			query.Set(name, value)
+			u.RawQuery = query.Encode()
+		}
+	}
+}
+
+// dataSourceURL returns the database url of --dsn, SQL2PB_DSN or DATABASE_URL, empty when the
+// connection flags are used. the environment is ignored once a connection flag is set.
+func dataSourceURL() string {
+	if "" != dsn {
+		return dsn
+	}
+	if len(connectionSet) > 0 {
+		return ""
+	}
+	for _, env := range dsnEnvs {
+		if source := os.Getenv(env); "" != source {
+			return source
+		}
+	}
+
+	return ""
+}
+
+// dbPassword returns the database password of --password, --password_file or SQL2PB_PASSWORD.
+func dbPassword() (string, error) {
+	if "" != password {
+		return password, nil
+	}
+	if "" != passwordFile {
+		data, err := os.ReadFile(passwordFile)
+		if nil != err {
+			return "", errors.Wrap(err, "read password file")
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	return os.Getenv(passwordEnv), nil
+}
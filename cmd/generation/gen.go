@@ -3,23 +3,82 @@ package generation
 import (
 	"database/sql"
 	"fmt"
-	"log"
+	"io/fs"
+	"os"
 	"sort"
+	"strings"
 
 	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
 
 	_ "github.com/go-sql-driver/mysql"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
 	"github.com/ch3nnn/sql2pb/cmd/generation/parser"
 	"github.com/ch3nnn/sql2pb/cmd/generation/tools/stringx"
 )
 
-func generateSchema(table string, ignoreTables, ignoreColumns []string, serviceName, fieldStyle, dbType string) (*parser.Schema, error) {
+// generateSchema reads the schema of the tables. previous is the protobuf file merged into with
+// --merge, whose field numbers are kept.
+func generateSchema(conf *Config, previous, table string, ignoreTables, ignoreColumns []string, serviceName, fieldStyle string) (*parser.Schema, error) {
+	if err := resolveOptions(); nil != err {
+		return nil, err
+	}
+
+	var err error
+	schema := parser.NewSchema("proto3", serviceName, goPackageName, packageName)
+	schema.VersionColumn = versionColumn
+	schema.UpdateStyle = updateStyle
+	schema.RespStyle = respStyle
+	schema.Pagination = pagination
+	schema.OrderBy = orderBy
+	schema.FilterStyle = filterStyle
+	schema.Batch = batch
+	schema.Stream = stream
+	schema.Ops = ops
+	schema.TableOps = conf.tableOps()
+	schema.TableIgnoreColumns = conf.tableIgnoreColumns()
+	schema.TableNames = conf.tableNames()
+	schema.ColumnNames = conf.columnNames()
+	schema.Types = conf.Types
+	schema.SchemaPrefix = schemaPrefix
+	schema.Singularize = singularize
+	schema.Singulars = conf.Singulars
+	schema.TrimPrefixes = trimPrefixes
+	schema.TrimSuffixes = trimSuffixes
+	if schema.Template, err = parser.NewTemplate(templateFile, templateDir); nil != err {
+		return nil, err
+	}
+	if schema.Naming, err = parser.NewNaming(naming, conf.Naming); nil != err {
+		return nil, err
+	}
+	custom, err := loadCatalog(catalogFile)
+	if nil != err {
+		return nil, err
+	}
+	if schema.Catalog, err = parser.NewCatalog(lang, custom); nil != err {
+		return nil, err
+	}
+	if lockFile != "" || merge {
+		if schema.Lock, err = parser.LoadLock(lockFile); nil != err {
+			return nil, errors.Wrapf(err, "load lock %s", lockFile)
+		}
+	}
+	if merge {
+		// the fields missing from the lock keep the numbers of the file merged into
+		if err := schema.Lock.ReadProto(strings.NewReader(previous)); nil != err {
+			return nil, errors.Wrap(err, "parse previous proto")
+		}
+	}
+	if err := schema.Validate(); nil != err {
+		return nil, err
+	}
+
 	db, err := db()
 	if err != nil {
 		return nil, err
 	}
+	defer db.Close()
 
 	dbs, err := dbSchema(db)
 	if nil != err {
@@ -31,7 +90,7 @@ func generateSchema(table string, ignoreTables, ignoreColumns []string, serviceN
 		return nil, err
 	}
 
-	schema := parser.NewSchema("proto3", serviceName, goPackageName, packageName)
+	defaultNames(schema, dbs)
 	if err := schema.TypesFromColumns(cols, ignoreTables, ignoreColumns, fieldStyle); nil != err {
 		return nil, err
 	}
@@ -43,21 +102,24 @@ func generateSchema(table string, ignoreTables, ignoreColumns []string, serviceN
 	return schema, nil
 }
 
-func db() (db *sql.DB, err error) {
-	var dataSourceName string
-
-	switch dbType {
-	case "mysql":
-		dataSourceName = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", user, password, host, port, dbname)
-	case "postgres":
-		dataSourceName = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable", host, port, user, password, dbname)
-	default:
-		log.Fatal("dbType not supported")
+func db() (*sql.DB, error) {
+	driver, dataSourceName, err := dataSource()
+	if nil != err {
+		return nil, err
 	}
 
-	return sql.Open(dbType, dataSourceName)
+	db, err := sql.Open(driver, dataSourceName)
+	if nil != err {
+		return nil, errors.Wrapf(err, "open %s database", driver)
+	}
+	if err := db.Ping(); nil != err {
+		db.Close()
+		return nil, errors.Wrapf(err, "connect to %s database", driver)
+	}
 
+	return db, nil
 }
+
 func dbSchema(db *sql.DB) (string, error) {
 	var schema, query string
 	switch dbType {
@@ -66,21 +128,36 @@ func dbSchema(db *sql.DB) (string, error) {
 	case "postgres":
 		query = `SELECT CURRENT_DATABASE()`
 	default:
-		log.Fatal("dbType not supported")
+		return "", fmt.Errorf("db type `%s` not supported. mysql | postgres", dbType)
 	}
 
 	if err := db.QueryRow(query).Scan(&schema); err != nil {
-		return "", err
+		return "", errors.Wrap(err, "query database name")
 	}
 
 	return schema, nil
 }
 
 func dbColumns(db *sql.DB, dbs, table, dbType string) ([]parser.Column, error) {
-	rows, err := db.Query(querySQL(dbs, dbType, table))
+	var tables []string
+	for _, t := range strings.Split(table, ",") {
+		if t = strings.TrimSpace(t); "" != t {
+			tables = append(tables, t)
+		}
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("the table is required. --table")
+	}
+
+	query, args, err := querySQL(dbs, dbType, tables)
 	if nil != err {
 		return nil, err
 	}
+
+	rows, err := db.Query(query, args...)
+	if nil != err {
+		return nil, errors.Wrapf(err, "query columns of tables %s", table)
+	}
 	defer rows.Close()
 
 	var cols []parser.Column
@@ -97,9 +174,11 @@ func dbColumns(db *sql.DB, dbs, table, dbType string) ([]parser.Column, error) {
 			&cs.ColumnType,
 			&cs.ColumnComment,
 			&cs.TableComment,
+			&cs.ColumnKey,
+			&cs.TableSchema,
 		)
 		if err != nil {
-			log.Fatal(errors.Wrapf(err, "scan error, table: %s, column: %s", cs.TableName, cs.ColumnName))
+			return nil, errors.Wrapf(err, "scan error, table: %s, column: %s", cs.TableName, cs.ColumnName)
 		}
 
 		if cs.TableComment == "" {
@@ -109,13 +188,15 @@ func dbColumns(db *sql.DB, dbs, table, dbType string) ([]parser.Column, error) {
 		cols = append(cols, cs)
 	}
 	if err := rows.Err(); nil != err {
-		return nil, err
+		return nil, errors.Wrapf(err, "query columns of tables %s", table)
 	}
 
 	return cols, nil
 }
 
-func querySQL(dbs, dbType, table string) (sql string) {
+// querySQL returns the columns query of the tables and its arguments. the names are bound as
+// arguments, never written in the query.
+func querySQL(dbs, dbType string, tables []string) (sql string, args []interface{}, err error) {
 	switch dbType {
 	case "mysql":
 		sql = `SELECT
@@ -128,20 +209,28 @@ func querySQL(dbs, dbType, table string) (sql string) {
 					c.NUMERIC_SCALE,
 					c.COLUMN_TYPE ,
 					c.COLUMN_COMMENT,
-					t.TABLE_COMMENT
+					t.TABLE_COMMENT,
+					c.COLUMN_KEY,
+					c.TABLE_SCHEMA
 				FROM
 					INFORMATION_SCHEMA.COLUMNS AS c
 				LEFT JOIN INFORMATION_SCHEMA.TABLES AS t ON
 					c.TABLE_NAME = t.TABLE_NAME
 					AND c.TABLE_SCHEMA = t.TABLE_SCHEMA
 				WHERE 
-					c.TABLE_SCHEMA = '%s'
-					AND c.TABLE_NAME = '%s' 
+					c.TABLE_SCHEMA = ?
+					AND c.TABLE_NAME IN (%s) 
 				ORDER BY 
 					c.TABLE_NAME,
 					c.ORDINAL_POSITION`
-		sql = fmt.Sprintf(sql, dbs, table)
+		// a placeholder per table
+		sql = fmt.Sprintf(sql, strings.TrimSuffix(strings.Repeat("?, ", len(tables)), ", "))
+		args = append(args, dbs)
+		for _, t := range tables {
+			args = append(args, t)
+		}
 	case "postgres":
+		// the relation is qualified with its schema rather than resolved by the search_path
 		sql = `SELECT
 					col.table_name AS TABLE_NAME,  -- 表名
 					col.column_name AS COLUMN_NAME, -- 字段名
@@ -153,23 +242,69 @@ func querySQL(dbs, dbType, table string) (sql string) {
 					col.numeric_scale AS NUMERIC_SCALE , -- 小数点后的精度基本单位的数
 					 col.udt_name AS COLUMN_TYPE,  -- 字段类型
 					COALESCE(pd.description, '') AS COLUMN_COMMENT, -- 字段注释
-					COALESCE(OBJ_DESCRIPTION('%s'::regclass, 'pg_class'), '') AS TABLE_COMMENT -- 表注释
+					COALESCE(OBJ_DESCRIPTION(cls.oid, 'pg_class'), '') AS TABLE_COMMENT, -- 表注释
+					CASE WHEN EXISTS (
+						SELECT 1 FROM pg_index AS i
+						WHERE i.indrelid = cls.oid
+						AND i.indisprimary
+						AND col.ordinal_position = ANY(i.indkey::int2[])
+					) THEN 'PRI' WHEN EXISTS (
+						SELECT 1 FROM pg_index AS i
+						WHERE i.indrelid = cls.oid
+						AND col.ordinal_position = ANY(i.indkey::int2[])
+					) THEN 'MUL' ELSE '' END AS COLUMN_KEY, -- 主键或索引列
+					col.table_schema AS TABLE_SCHEMA -- 模式名
 				FROM
 					information_schema.columns AS col
+				JOIN
+					pg_class AS cls
+				ON
+					cls.oid = (QUOTE_IDENT(col.table_schema) || '.' || QUOTE_IDENT(col.table_name))::regclass
 				LEFT JOIN
 					pg_description AS pd
 				ON
-					col.table_name::regclass = pd.objoid
+					cls.oid = pd.objoid
 					AND col.ordinal_position = pd.objsubid
 				WHERE
-					col.table_name ='%s' 
+					col.table_name::text = ANY($1::text[])
 					AND 
-					col.table_catalog = '%s'  -- 数据库名称
-				ORDER BY col.table_name, col.ORDINAL_POSITION`
-		sql = fmt.Sprintf(sql, table, table, dbs)
+					col.table_catalog::text = $2  -- 数据库名称
+					AND
+					(
+						col.table_schema::text = ANY($3::text[])
+						OR CARDINALITY($3::text[]) = 0 AND col.table_schema = CURRENT_SCHEMA()
+					)  -- 模式名, 默认当前模式
+				ORDER BY col.table_schema, col.table_name, col.ORDINAL_POSITION`
+		// a nil slice is bound as null rather than an empty array
+		args = []interface{}{pq.Array(tables), dbs, pq.Array(append([]string{}, schemas...))}
 	default:
-		log.Fatal("dbType not supported")
+		return "", nil, fmt.Errorf("db type `%s` not supported. mysql | postgres", dbType)
+	}
+
+	return sql, args, nil
+}
+
+// readOutput reads the output file, a missing file is empty.
+func readOutput() (string, error) {
+	data, err := os.ReadFile(outputFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if nil != err {
+		return "", errors.Wrapf(err, "read %s", outputFile)
 	}
 
-	return
+	return string(data), nil
+}
+
+// diffOutput returns the unified diff of the output file to the generated one, empty when it is
+// up to date.
+func diffOutput(previous, out string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(previous),
+		B:        difflib.SplitLines(out),
+		FromFile: outputFile,
+		ToFile:   outputFile + " (generated)",
+		Context:  3,
+	})
 }
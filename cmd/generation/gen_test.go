@@ -0,0 +1,108 @@
+package generation
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+var columnsHeader = []string{
+	"TABLE_NAME", "COLUMN_NAME", "IS_NULLABLE", "DATA_TYPE", "CHARACTER_MAXIMUM_LENGTH", "NUMERIC_PRECISION",
+	"NUMERIC_SCALE", "COLUMN_TYPE", "COLUMN_COMMENT", "TABLE_COMMENT", "COLUMN_KEY", "TABLE_SCHEMA",
+}
+
+func TestDbColumnsMySQL(t *testing.T) {
+	tests := []struct {
+		name   string
+		table  string
+		query  string
+		tables []driver.Value
+	}{
+		{name: "quote", table: "a'b", query: `c.TABLE_SCHEMA = \?\s+AND c.TABLE_NAME IN \(\?\)`, tables: []driver.Value{"a'b"}},
+		{name: "tables", table: "a, b", query: `c.TABLE_SCHEMA = \?\s+AND c.TABLE_NAME IN \(\?, \?\)`, tables: []driver.Value{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if nil != err {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			rows := sqlmock.NewRows(columnsHeader).
+				AddRow(tt.tables[0], "id", "NO", "bigint", nil, 19, 0, "bigint", "", "", "PRI", "shop")
+			mock.ExpectQuery(tt.query).WithArgs(append([]driver.Value{"shop"}, tt.tables...)...).WillReturnRows(rows)
+
+			cols, err := dbColumns(db, "shop", tt.table, "mysql")
+			if nil != err {
+				t.Fatal(err)
+			}
+			if len(cols) != 1 || cols[0].TableName != tt.tables[0] || cols[0].TableSchema != "shop" {
+				t.Errorf("columns = %+v", cols)
+			}
+			if err := mock.ExpectationsWereMet(); nil != err {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestDbColumnsPostgres(t *testing.T) {
+	tests := []struct {
+		name    string
+		table   string
+		schemas []string
+		tables  []string
+	}{
+		{name: "quote", table: "a'b", schemas: []string{"public", "audit"}, tables: []string{"a'b"}},
+		{name: "tables", table: "a,b", schemas: []string{"public"}, tables: []string{"a", "b"}},
+		{name: "current schema", table: "a", tables: []string{"a"}},
+	}
+	defer func(s []string) { schemas = s }(schemas)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if nil != err {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			schemas = tt.schemas
+			rows := sqlmock.NewRows(columnsHeader).
+				AddRow(tt.tables[0], "id", "NO", "int8", nil, 64, 0, "int8", "", "", "PRI", "public")
+			query := `col.table_name::text = ANY\(\$1::text\[\]\)\s+AND\s+col.table_catalog::text = \$2` +
+				`[\s\S]*col.table_schema::text = ANY\(\$3::text\[\]\)\s+OR CARDINALITY\(\$3::text\[\]\) = 0 AND col.table_schema = CURRENT_SCHEMA\(\)`
+			mock.ExpectQuery(query).
+				WithArgs(pq.Array(tt.tables), "shop", pq.Array(append([]string{}, tt.schemas...))).
+				WillReturnRows(rows)
+
+			cols, err := dbColumns(db, "shop", tt.table, "postgres")
+			if nil != err {
+				t.Fatal(err)
+			}
+			if len(cols) != 1 || cols[0].TableName != tt.tables[0] || cols[0].TableSchema != "public" {
+				t.Errorf("columns = %+v", cols)
+			}
+			if err := mock.ExpectationsWereMet(); nil != err {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestDbColumnsTableRequired(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := dbColumns(db, "shop", " , ", "mysql"); nil == err {
+		t.Error("expected an error without table")
+	}
+	if err := mock.ExpectationsWereMet(); nil != err {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,57 @@
+package generation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ch3nnn/sql2pb/cmd/generation/parser"
+	"github.com/ch3nnn/sql2pb/cmd/generation/tools/stringx"
+)
+
+// defaultPorts are the database ports per db type, used when --port is not set.
+var defaultPorts = map[string]int{
+	"mysql":    3306,
+	"postgres": 5432,
+}
+
+// resolveOptions validates the connection and generation options before connecting to the
+// database, and sets the port default of the db type.
+func resolveOptions() error {
+	if "" == dataSourceURL() {
+		defaultPort, ok := defaultPorts[dbType]
+		if !ok {
+			return fmt.Errorf("db type `%s` not supported. mysql | postgres", dbType)
+		}
+		if 0 == port {
+			port = defaultPort
+		}
+		if "" == dbname {
+			return fmt.Errorf("the database name is required. --dbname or --dsn")
+		}
+	}
+	if "" == table {
+		return fmt.Errorf("the table is required. --table")
+	}
+	switch fieldStyle {
+	case "sql_pb", "sqlPb":
+	default:
+		return fmt.Errorf("field style `%s` not supported. sql_pb | sqlPb", fieldStyle)
+	}
+
+	return nil
+}
+
+// defaultNames sets the service name, the package and the go package of the schema which are not
+// set after the database name.
+func defaultNames(schema *parser.Schema, dbs string) {
+	name := strings.ReplaceAll(dbs, "-", "_")
+	if "" == schema.ServiceName {
+		schema.ServiceName = stringx.From(name).ToCamel()
+	}
+	if "" == schema.Package {
+		schema.Package = strings.ToLower(name)
+	}
+	if "" == schema.GoPackage {
+		schema.GoPackage = "./" + schema.Package
+	}
+}
@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	// gen comment language
+	LangZh = "zh"
+	LangEn = "en"
+)
+
+// Catalog is the text of the generated comments keyed by message id. a text may hold a `%s`
+// verb replaced by the table comment.
+type Catalog map[string]string
+
+// catalogs are the built-in catalogs per language.
+var catalogs = map[string]Catalog{
+	LangZh: {
+		"rpc.create":            "创建%s",
+		"rpc.update":            "更新%s",
+		"rpc.delete":            "根据 %s id 删除",
+		"rpc.get":               "根据 %s id 获取详情",
+		"rpc.list":              "%s 列表",
+		"rpc.batch_create":      "批量创建%s",
+		"rpc.batch_update":      "批量更新%s",
+		"rpc.batch_delete":      "根据 %s id 批量删除",
+		"rpc.batch_get":         "根据 %s id 批量获取详情",
+		"rpc.export":            "%s 导出",
+		"field.page":            "页码",
+		"field.page_size":       "每页数量",
+		"field.page_token":      "分页游标",
+		"field.next_page_token": "下一页游标, 为空表示没有更多数据",
+		"field.count":           "总数",
+		"field.page_count":      "页码总数",
+		"field.total_size":      "总数",
+		"field.update_mask":     "更新字段",
+		"field.order_by":        "排序, 例如: create_at desc, id",
+		"field.order_by_field":  "排序字段",
+		"field.desc":            "是否倒序",
+		"field.results":         "与请求顺序一致的结果",
+		"enum.order_by":         "%s 排序字段",
+		"filter.eq":             "等于",
+		"filter.gt":             "大于",
+		"filter.gte":            "大于等于",
+		"filter.lt":             "小于",
+		"filter.lte":            "小于等于",
+		"filter.in":             "包含",
+		"filter.like":           "模糊匹配",
+		"filter.prefix":         "前缀匹配",
+		"filter.is_null":        "是否为空",
+	},
+	LangEn: {
+		"rpc.create":            "Create %s",
+		"rpc.update":            "Update %s",
+		"rpc.delete":            "Delete %s by id",
+		"rpc.get":               "Get %s by id",
+		"rpc.list":              "List %s",
+		"rpc.batch_create":      "Batch create %s",
+		"rpc.batch_update":      "Batch update %s",
+		"rpc.batch_delete":      "Batch delete %s by id",
+		"rpc.batch_get":         "Batch get %s by id",
+		"rpc.export":            "Export %s",
+		"field.page":            "page number",
+		"field.page_size":       "page size",
+		"field.page_token":      "page token",
+		"field.next_page_token": "next page token, empty when there are no more results",
+		"field.count":           "total count",
+		"field.page_count":      "page count",
+		"field.total_size":      "total size",
+		"field.update_mask":     "fields to update",
+		"field.order_by":        "order by, e.g. create_at desc, id",
+		"field.order_by_field":  "sort field",
+		"field.desc":            "descending order",
+		"field.results":         "results in request order",
+		"enum.order_by":         "%s sort fields",
+		"filter.eq":             "equal to",
+		"filter.gt":             "greater than",
+		"filter.gte":            "greater than or equal to",
+		"filter.lt":             "less than",
+		"filter.lte":            "less than or equal to",
+		"filter.in":             "in the list",
+		"filter.like":           "like pattern",
+		"filter.prefix":         "starts with",
+		"filter.is_null":        "is null",
+	},
+}
+
+// NewCatalog returns the built-in catalog of a language overridden by custom.
+func NewCatalog(lang string, custom Catalog) (Catalog, error) {
+	builtin, ok := catalogs[lang]
+	if !ok {
+		return nil, fmt.Errorf("lang `%s` not supported. %s | %s", lang, LangZh, LangEn)
+	}
+
+	c := Catalog{}
+	for id, text := range builtin {
+		c[id] = text
+	}
+	for id, text := range custom {
+		c[id] = text
+	}
+
+	return c, nil
+}
+
+// T returns the text of a message id formatted with args. a missing id falls back to the zh
+// catalog, then to the id itself.
+func (c Catalog) T(id string, args ...any) string {
+	text, ok := c[id]
+	if !ok {
+		if text, ok = catalogs[LangZh][id]; !ok {
+			text = id
+		}
+	}
+	if !strings.Contains(text, "%") {
+		return text
+	}
+
+	return fmt.Sprintf(text, args...)
+}
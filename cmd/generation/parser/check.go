@@ -0,0 +1,215 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/emicklei/proto"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ChangeWire breaks clients decoding messages with the previous file
+	ChangeWire = "wire"
+	// ChangeSource breaks code generated from the previous file
+	ChangeSource = "source"
+)
+
+// Change is a breaking change between two protobuf files.
+type Change struct {
+	Kind string
+	Text string
+}
+
+func (c Change) String() string {
+	return c.Kind + ": " + c.Text
+}
+
+type protoField struct {
+	name     string
+	typ      string
+	tag      int
+	optional bool
+}
+
+type protoMessage struct {
+	tags          map[int]protoField
+	names         map[string]protoField
+	reserved      []proto.Range
+	reservedNames []string
+}
+
+func (m *protoMessage) isReserved(tag int) bool {
+	for _, r := range m.reserved {
+		if inRange(r, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func inRange(r proto.Range, tag int) bool {
+	return tag >= r.From && (r.Max || tag <= r.To)
+}
+
+type protoFile struct {
+	messages map[string]*protoMessage
+	enums    map[string]map[string]int
+	rpcs     map[string]*proto.RPC
+}
+
+func parseProto(r io.Reader) (*protoFile, error) {
+	definition, err := proto.NewParser(r).Parse()
+	if nil != err {
+		return nil, err
+	}
+
+	f := &protoFile{
+		messages: map[string]*protoMessage{},
+		enums:    map[string]map[string]int{},
+		rpcs:     map[string]*proto.RPC{},
+	}
+	proto.Walk(definition,
+		proto.WithMessage(func(m *proto.Message) {
+			msg := &protoMessage{tags: map[int]protoField{}, names: map[string]protoField{}}
+			for _, e := range m.Elements {
+				switch e := e.(type) {
+				case *proto.NormalField:
+					typ := e.Type
+					if e.Repeated {
+						typ = "repeated " + typ
+					}
+					field := protoField{name: e.Name, typ: typ, tag: e.Sequence, optional: e.Optional}
+					msg.tags[field.tag] = field
+					msg.names[field.name] = field
+				case *proto.MapField:
+					typ := fmt.Sprintf("map<%s, %s>", e.KeyType, e.Type)
+					field := protoField{name: e.Name, typ: typ, tag: e.Sequence}
+					msg.tags[field.tag] = field
+					msg.names[field.name] = field
+				case *proto.Reserved:
+					msg.reserved = append(msg.reserved, e.Ranges...)
+					msg.reservedNames = append(msg.reservedNames, e.FieldNames...)
+				}
+			}
+			f.messages[m.Name] = msg
+		}),
+		proto.WithEnum(func(e *proto.Enum) {
+			values := map[string]int{}
+			for _, v := range e.Elements {
+				if v, ok := v.(*proto.EnumField); ok {
+					values[v.Name] = v.Integer
+				}
+			}
+			f.enums[e.Name] = values
+		}),
+		proto.WithRPC(func(r *proto.RPC) {
+			f.rpcs[r.Name] = r
+		}),
+	)
+
+	return f, nil
+}
+
+// BreakingChanges reports the changes of the current protobuf file breaking the
+// clients of the previous one, sorted by kind and text.
+func BreakingChanges(previous, current io.Reader) ([]Change, error) {
+	before, err := parseProto(previous)
+	if nil != err {
+		return nil, errors.Wrap(err, "parse previous proto")
+	}
+	after, err := parseProto(current)
+	if nil != err {
+		return nil, errors.Wrap(err, "parse current proto")
+	}
+
+	var changes []Change
+	add := func(kind, format string, args ...interface{}) {
+		changes = append(changes, Change{Kind: kind, Text: fmt.Sprintf(format, args...)})
+	}
+
+	for name, old := range before.messages {
+		msg, ok := after.messages[name]
+		if !ok {
+			add(ChangeSource, "message `%s` removed", name)
+			continue
+		}
+
+		for tag, field := range old.tags {
+			if now, ok := msg.tags[tag]; ok {
+				if now.typ != field.typ {
+					add(ChangeWire, "field `%s.%s` = %d changed type from `%s` to `%s`", name, field.name, tag, field.typ, now.typ)
+				}
+				if now.name != field.name {
+					add(ChangeSource, "field `%s.%s` = %d renamed to `%s`", name, field.name, tag, now.name)
+				}
+				if now.optional != field.optional {
+					add(ChangeSource, "field `%s.%s` = %d changed optional from %t to %t", name, field.name, tag, field.optional, now.optional)
+				}
+				continue
+			}
+
+			if now, ok := msg.names[field.name]; ok {
+				add(ChangeWire, "field `%s.%s` renumbered from %d to %d", name, field.name, tag, now.tag)
+				continue
+			}
+
+			if msg.isReserved(tag) {
+				add(ChangeSource, "field `%s.%s` = %d removed", name, field.name, tag)
+			} else {
+				add(ChangeWire, "field `%s.%s` = %d removed without reserving its number", name, field.name, tag)
+			}
+		}
+	}
+
+	for name, old := range before.enums {
+		values, ok := after.enums[name]
+		if !ok {
+			add(ChangeSource, "enum `%s` removed or renamed", name)
+			continue
+		}
+
+		for value, number := range old {
+			now, ok := values[value]
+			if !ok {
+				add(ChangeSource, "enum value `%s.%s` = %d removed or renamed", name, value, number)
+				continue
+			}
+			if now != number {
+				add(ChangeWire, "enum value `%s.%s` renumbered from %d to %d", name, value, number, now)
+			}
+		}
+	}
+
+	for name, old := range before.rpcs {
+		rpc, ok := after.rpcs[name]
+		if !ok {
+			add(ChangeSource, "rpc `%s` removed", name)
+			continue
+		}
+
+		if rpc.RequestType != old.RequestType || rpc.StreamsRequest != old.StreamsRequest {
+			add(ChangeWire, "rpc `%s` changed request from `%s` to `%s`", name, rpcType(old.StreamsRequest, old.RequestType), rpcType(rpc.StreamsRequest, rpc.RequestType))
+		}
+		if rpc.ReturnsType != old.ReturnsType || rpc.StreamsReturns != old.StreamsReturns {
+			add(ChangeWire, "rpc `%s` changed response from `%s` to `%s`", name, rpcType(old.StreamsReturns, old.ReturnsType), rpcType(rpc.StreamsReturns, rpc.ReturnsType))
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return changes[i].Kind > changes[j].Kind
+		}
+		return changes[i].Text < changes[j].Text
+	})
+
+	return changes, nil
+}
+
+func rpcType(stream bool, typ string) string {
+	if stream {
+		return "stream " + typ
+	}
+	return typ
+}
@@ -0,0 +1,205 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const checkPrevious = `syntax = "proto3";
+
+message Users {
+  int64 id = 1;
+  string name = 2;
+  int64 age = 3;
+  optional string email = 4;
+  string phone = 5;
+  string city = 6;
+}
+
+message Logs {
+  int64 id = 1;
+}
+
+enum UsersStatus {
+  ON = 0;
+  OFF = 1;
+  LOCKED = 2;
+}
+
+enum LogsLevel {
+  INFO = 0;
+}
+
+service User {
+  rpc GetUsers(Users) returns (Users);
+  rpc ListUsers(Users) returns (Users);
+  rpc ExportUsers(Users) returns (stream Users);
+  rpc GetLogs(Logs) returns (Logs);
+}
+`
+
+func TestBreakingChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		want    []string
+	}{
+		{
+			name:    "unchanged",
+			current: checkPrevious,
+		},
+		{
+			name: "fields",
+			current: `syntax = "proto3";
+
+message Users {
+  reserved 5;
+  int64 id = 1;
+  string username = 2;
+  string age = 3;
+  string email = 4;
+  string city = 7;
+}
+
+message Logs {
+  int64 id = 1;
+}
+
+enum UsersStatus {
+  ON = 0;
+  OFF = 1;
+  LOCKED = 2;
+}
+
+enum LogsLevel {
+  INFO = 0;
+}
+
+service User {
+  rpc GetUsers(Users) returns (Users);
+  rpc ListUsers(Users) returns (Users);
+  rpc ExportUsers(Users) returns (stream Users);
+  rpc GetLogs(Logs) returns (Logs);
+}
+`,
+			want: []string{
+				"wire: field `Users.age` = 3 changed type from `int64` to `string`",
+				"wire: field `Users.city` renumbered from 6 to 7",
+				"source: field `Users.email` = 4 changed optional from true to false",
+				"source: field `Users.name` = 2 renamed to `username`",
+				"source: field `Users.phone` = 5 removed",
+			},
+		},
+		{
+			name: "field removed without reserving",
+			current: `syntax = "proto3";
+
+message Users {
+  int64 id = 1;
+  string name = 2;
+  int64 age = 3;
+  optional string email = 4;
+  string city = 6;
+}
+
+message Logs {
+  int64 id = 1;
+}
+
+enum UsersStatus {
+  ON = 0;
+  OFF = 1;
+  LOCKED = 2;
+}
+
+enum LogsLevel {
+  INFO = 0;
+}
+
+service User {
+  rpc GetUsers(Users) returns (Users);
+  rpc ListUsers(Users) returns (Users);
+  rpc ExportUsers(Users) returns (stream Users);
+  rpc GetLogs(Logs) returns (Logs);
+}
+`,
+			want: []string{
+				"wire: field `Users.phone` = 5 removed without reserving its number",
+			},
+		},
+		{
+			name: "definitions",
+			current: `syntax = "proto3";
+
+message Users {
+  int64 id = 1;
+  string name = 2;
+  int64 age = 3;
+  optional string email = 4;
+  string phone = 5;
+  string city = 6;
+}
+
+enum UsersStatus {
+  ON = 0;
+  LOCKED = 1;
+}
+
+service User {
+  rpc GetUsers(Logs) returns (Users);
+  rpc ListUsers(Users) returns (Logs);
+  rpc ExportUsers(Users) returns (Users);
+}
+`,
+			want: []string{
+				"wire: enum value `UsersStatus.LOCKED` renumbered from 2 to 1",
+				"wire: rpc `ExportUsers` changed response from `stream Users` to `Users`",
+				"wire: rpc `GetUsers` changed request from `Users` to `Logs`",
+				"wire: rpc `ListUsers` changed response from `Users` to `Logs`",
+				"source: enum `LogsLevel` removed or renamed",
+				"source: enum value `UsersStatus.OFF` = 1 removed or renamed",
+				"source: message `Logs` removed",
+				"source: rpc `GetLogs` removed",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := BreakingChanges(strings.NewReader(checkPrevious), strings.NewReader(tt.current))
+			if nil != err {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, c := range changes {
+				got = append(got, c.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BreakingChanges() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreakingChangesMerged(t *testing.T) {
+	previous := strings.Replace(mergeGenerated, "message GetUsersReq {", "// 手写\nmessage Address {\n  string city = 1;\n}\n\nmessage GetUsersReq {", 1)
+	merged, err := Merge(previous, mergeGenerated)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	changes, err := BreakingChanges(strings.NewReader(previous), strings.NewReader(merged))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if len(changes) > 0 {
+		t.Errorf("BreakingChanges() = %v, want none", changes)
+	}
+}
+
+func TestBreakingChangesError(t *testing.T) {
+	if _, err := BreakingChanges(strings.NewReader("message {"), strings.NewReader(checkPrevious)); nil == err {
+		t.Error("expected an error for an invalid previous proto")
+	}
+}
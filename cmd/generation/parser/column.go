@@ -4,6 +4,7 @@ import "database/sql"
 
 type Column struct {
 	Style                  string
+	TableSchema            string
 	TableName              string
 	TableComment           string
 	ColumnName             string
@@ -14,4 +15,5 @@ type Column struct {
 	NumericScale           sql.NullInt64
 	ColumnType             string
 	ColumnComment          string
+	ColumnKey              string
 }
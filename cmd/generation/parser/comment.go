@@ -0,0 +1,32 @@
+package parser
+
+import (
+	"strings"
+)
+
+// Comment returns text as leading `//` comment lines prefixed by indent, so protoc-gen-doc and
+// buf pick it up as documentation. each line of a multi-line text is commented, an empty text
+// returns no comment.
+func Comment(indent, text string) string {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if text == "" {
+		return ""
+	}
+
+	var buf strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(strings.ReplaceAll(line, "\r", ""), " \t")
+		if line == "" {
+			buf.WriteString(indent + "//\n")
+			continue
+		}
+		buf.WriteString(indent + "// " + line + "\n")
+	}
+
+	return buf.String()
+}
+
+// OneLine returns text joined on a single line, for comments embedded in a line such as banners.
+func OneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
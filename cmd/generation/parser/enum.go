@@ -20,7 +20,7 @@ type Enum struct {
 func (e *Enum) String() string {
 	buf := new(bytes.Buffer)
 
-	buf.WriteString(fmt.Sprintf("// %s \n", e.Comment))
+	buf.WriteString(Comment("", e.Comment))
 	buf.WriteString(fmt.Sprintf("enum %s {\n", e.Name))
 
 	for _, f := range e.Fields {
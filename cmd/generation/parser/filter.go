@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/ch3nnn/sql2pb/cmd/generation/tools/stringx"
+)
+
+// wrapperTypes maps the wrapper well-known types to the scalar type they wrap. a wrapper field is
+// filtered by the operators of its scalar.
+var wrapperTypes = map[string]string{
+	"google.protobuf.Int32Value":  "int32",
+	"google.protobuf.Int64Value":  "int64",
+	"google.protobuf.DoubleValue": "double",
+	"google.protobuf.StringValue": "string",
+	"google.protobuf.BoolValue":   "bool",
+	"google.protobuf.BytesValue":  "bytes",
+}
+
+// filterOperatorName returns the name of the operator message filtering a field type. the package
+// of a qualified type is part of the name, e.g. GoogleProtobufTimestampOperatorFilter. an enum or message
+// type is suffixed with OperatorFilter so it never clashes with the filter message of a table,
+// e.g. the enum of the status column of user and the table user_status.
+func filterOperatorName(typ string, nullable bool) string {
+	if scalar, ok := wrapperTypes[typ]; ok {
+		typ = scalar
+	}
+	name := stringx.From(strings.ReplaceAll(typ, ".", "_")).ToCamel()
+	switch typ {
+	case "int32", "int64", "double", "string", "bool", "bytes":
+		name += "Filter"
+	default:
+		name += "OperatorFilter"
+	}
+	if nullable {
+		name = "Nullable" + name
+	}
+
+	return name
+}
+
+// filterOperatorFields returns the operators supported by a field type. numeric and time columns,
+// including the Timestamp and Duration well-known types, support ranges, strings support like and prefix matching and nullable columns support is_null.
+func filterOperatorFields(c Catalog, typ string, nullable bool) []MessageField {
+	if scalar, ok := wrapperTypes[typ]; ok {
+		typ = scalar
+	}
+
+	var fields []MessageField
+	switch typ {
+	case "int32", "int64", "double", "google.protobuf.Timestamp", "google.protobuf.Duration":
+		fields = []MessageField{
+			{Typ: "optional " + typ, Name: "eq", Comment: c.T("filter.eq")},
+			{Typ: "optional " + typ, Name: "gt", Comment: c.T("filter.gt")},
+			{Typ: "optional " + typ, Name: "gte", Comment: c.T("filter.gte")},
+			{Typ: "optional " + typ, Name: "lt", Comment: c.T("filter.lt")},
+			{Typ: "optional " + typ, Name: "lte", Comment: c.T("filter.lte")},
+			{Typ: "repeated " + typ, Name: "in", Comment: c.T("filter.in")},
+		}
+	case "string":
+		fields = []MessageField{
+			{Typ: "optional " + typ, Name: "eq", Comment: c.T("filter.eq")},
+			{Typ: "repeated " + typ, Name: "in", Comment: c.T("filter.in")},
+			{Typ: "optional " + typ, Name: "like", Comment: c.T("filter.like")},
+			{Typ: "optional " + typ, Name: "prefix", Comment: c.T("filter.prefix")},
+		}
+	case "bool", "bytes":
+		fields = []MessageField{
+			{Typ: "optional " + typ, Name: "eq", Comment: c.T("filter.eq")},
+		}
+	default:
+		// enum
+		fields = []MessageField{
+			{Typ: "optional " + typ, Name: "eq", Comment: c.T("filter.eq")},
+			{Typ: "repeated " + typ, Name: "in", Comment: c.T("filter.in")},
+		}
+	}
+	if nullable {
+		fields = append(fields, MessageField{Typ: "optional bool", Name: "is_null", Comment: c.T("filter.is_null")})
+	}
+
+	for i := range fields {
+		fields[i].tag = i + 1
+	}
+
+	return fields
+}
+
+// GenFilterOperatorMessages gen the operator messages used by the filter messages of every message
+func (s *Schema) GenFilterOperatorMessages() []*Message {
+	var msgs []*Message
+	seen := map[string]bool{}
+	for _, m := range s.Messages {
+		if m.FilterStyle != FilterStyleOperator || !m.HasOp(OpList) {
+			continue
+		}
+		for _, field := range m.Fields {
+			name := filterOperatorName(field.Typ, field.Nullable)
+			if !m.isFilterField(field) || seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			msg := &Message{Name: name, Fields: filterOperatorFields(s.Catalog, field.Typ, field.Nullable)}
+			s.Lock.apply(msg)
+			msgs = append(msgs, msg)
+		}
+	}
+
+	return msgs
+}
@@ -0,0 +1,36 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterOperator(t *testing.T) {
+	tests := []struct {
+		typ       string
+		name      string
+		operators []string
+	}{
+		{typ: "int64", name: "Int64Filter", operators: []string{"eq", "gt", "gte", "lt", "lte", "in"}},
+		{typ: "string", name: "StringFilter", operators: []string{"eq", "in", "like", "prefix"}},
+		{typ: "bool", name: "BoolFilter", operators: []string{"eq"}},
+		{typ: "UsersStatus", name: "UsersStatusOperatorFilter", operators: []string{"eq", "in"}},
+		{typ: "google.protobuf.Timestamp", name: "GoogleProtobufTimestampOperatorFilter", operators: []string{"eq", "gt", "gte", "lt", "lte", "in"}},
+		{typ: "google.protobuf.Duration", name: "GoogleProtobufDurationOperatorFilter", operators: []string{"eq", "gt", "gte", "lt", "lte", "in"}},
+		{typ: "google.protobuf.Int64Value", name: "Int64Filter", operators: []string{"eq", "gt", "gte", "lt", "lte", "in"}},
+		{typ: "google.protobuf.StringValue", name: "StringFilter", operators: []string{"eq", "in", "like", "prefix"}},
+	}
+	for _, tt := range tests {
+		if name := filterOperatorName(tt.typ, false); name != tt.name {
+			t.Errorf("filterOperatorName(%q) = %s, want %s", tt.typ, name, tt.name)
+		}
+
+		var operators []string
+		for _, field := range filterOperatorFields(Catalog{}, tt.typ, false) {
+			operators = append(operators, field.Name)
+		}
+		if !reflect.DeepEqual(operators, tt.operators) {
+			t.Errorf("filterOperatorFields(%q) = %v, want %v", tt.typ, operators, tt.operators)
+		}
+	}
+}
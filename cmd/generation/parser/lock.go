@@ -0,0 +1,206 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+
+	"golang.org/x/exp/slices"
+)
+
+// Lock persists the field numbers of every generated message across regenerations, so adding or
+// dropping a column never renumbers the existing fields.
+type Lock struct {
+	// Messages maps a message name to its field names and numbers. the fields of dropped columns
+	// are kept so their numbers are never reused.
+	Messages map[string]map[string]int `json:"messages"`
+
+	// Enums maps an order by enum name to its value names and numbers, so adding an index never
+	// renumbers the existing values.
+	Enums map[string]map[string]int `json:"enums,omitempty"`
+
+	// previous holds the messages of the protobuf file merged into. their field numbers are used
+	// by the messages missing from the lock and their reserved statements are kept
+	previous map[string]*protoMessage
+
+	// previousEnums holds the enum values of the protobuf file merged into
+	previousEnums map[string]map[string]int
+}
+
+// LoadLock reads a lock file, a missing file returns an empty lock.
+func LoadLock(path string) (*Lock, error) {
+	l := &Lock{Messages: map[string]map[string]int{}, Enums: map[string]map[string]int{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return l, nil
+	}
+	if nil != err {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, l); nil != err {
+		return nil, err
+	}
+	if nil == l.Messages {
+		l.Messages = map[string]map[string]int{}
+	}
+	if nil == l.Enums {
+		l.Enums = map[string]map[string]int{}
+	}
+
+	return l, nil
+}
+
+// Save writes the lock file.
+func (l *Lock) Save(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if nil != err {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// ReadProto reads the field numbers, the enum values and the reserved statements of the protobuf
+// file the output is merged into, so the messages and enums missing from the lock keep them.
+func (l *Lock) ReadProto(r io.Reader) error {
+	f, err := parseProto(r)
+	if nil != err {
+		return err
+	}
+	l.previous = f.messages
+	l.previousEnums = f.enums
+
+	return nil
+}
+
+// apply numbers the fields of msg with their locked numbers. a new field is numbered after the
+// greatest number ever used or reserved by the message, or used by the merged file, and is added
+// to the lock. the locked fields missing from msg and from the merged file are reserved, with the
+// reserved statements of the merged file.
+func (l *Lock) apply(msg *Message) {
+	if nil == l {
+		return
+	}
+
+	previous, ok := l.previous[msg.Name]
+	if !ok {
+		previous = &protoMessage{}
+	}
+
+	fields, ok := l.Messages[msg.Name]
+	if !ok {
+		fields = map[string]int{}
+		for tag, field := range previous.tags {
+			fields[field.name] = tag
+		}
+		l.Messages[msg.Name] = fields
+	}
+
+	var next int
+	for _, tag := range fields {
+		if tag > next {
+			next = tag
+		}
+	}
+	for tag := range previous.tags {
+		if tag > next {
+			next = tag
+		}
+	}
+	for _, r := range previous.reserved {
+		if r.Max && r.From > next {
+			next = r.From
+		}
+		if !r.Max && r.To > next {
+			next = r.To
+		}
+	}
+
+	msg.Fields = append([]MessageField{}, msg.Fields...)
+	current := map[string]bool{}
+	var tags []int
+	for i, f := range msg.Fields {
+		tag, ok := fields[f.Name]
+		if !ok {
+			next++
+			tag = next
+			fields[f.Name] = tag
+		}
+		msg.Fields[i].tag = tag
+		current[f.Name] = true
+		tags = append(tags, tag)
+	}
+
+	// the reserved statements of a field added back no longer apply
+	msg.Reserved, msg.reservedRanges, msg.reservedNames = nil, nil, nil
+	for _, r := range previous.reserved {
+		if !slices.ContainsFunc(tags, func(tag int) bool { return inRange(r, tag) }) {
+			msg.reservedRanges = append(msg.reservedRanges, r)
+		}
+	}
+	for _, name := range previous.reservedNames {
+		if !current[name] {
+			msg.reservedNames = append(msg.reservedNames, name)
+		}
+	}
+	for name, tag := range fields {
+		// the fields of the merged file are kept by Merge, e.g. a field added by hand
+		if _, ok := previous.names[name]; !ok && !current[name] {
+			msg.Reserved = append(msg.Reserved, MessageField{Name: name, tag: tag})
+		}
+	}
+	sort.Slice(msg.Reserved, func(i, j int) bool {
+		return msg.Reserved[i].tag < msg.Reserved[j].tag
+	})
+}
+
+// applyEnum numbers the values of enum with their locked numbers. a new value is numbered after the
+// greatest number ever used by the enum, or used by the merged file, and is added to the lock. the
+// values missing from enum stay in the lock so their numbers are never reused.
+func (l *Lock) applyEnum(enum *Enum) {
+	if nil == l {
+		return
+	}
+	if nil == l.Enums {
+		l.Enums = map[string]map[string]int{}
+	}
+
+	values, ok := l.Enums[enum.Name]
+	if !ok {
+		values = map[string]int{}
+		for name, tag := range l.previousEnums[enum.Name] {
+			values[name] = tag
+		}
+		l.Enums[enum.Name] = values
+	}
+
+	var next int
+	for _, tag := range values {
+		if tag > next {
+			next = tag
+		}
+	}
+	for _, tag := range l.previousEnums[enum.Name] {
+		if tag > next {
+			next = tag
+		}
+	}
+
+	enum.Fields = append([]EnumField{}, enum.Fields...)
+	for i, f := range enum.Fields {
+		tag, ok := values[f.name]
+		if !ok {
+			// the unspecified value keeps 0
+			if 0 != f.tag {
+				next++
+				tag = next
+			}
+			values[f.name] = tag
+		}
+		enum.Fields[i].tag = tag
+	}
+}
@@ -0,0 +1,176 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+func lockMessage(names ...string) *Message {
+	msg := &Message{Name: "Users"}
+	for _, name := range names {
+		msg.Fields = append(msg.Fields, MessageField{Typ: "string", Name: name})
+	}
+
+	return msg
+}
+
+func fieldTags(msg *Message) map[string]int {
+	tags := map[string]int{}
+	for _, f := range msg.Fields {
+		tags[f.Name] = f.tag
+	}
+
+	return tags
+}
+
+func TestLockApply(t *testing.T) {
+	tests := []struct {
+		name         string
+		locked       map[string]int
+		previous     string
+		fields       []string
+		want         map[string]int
+		wantTags     string
+		wantReserved string
+		wantLock     map[string]int
+	}{
+		{
+			name:     "new message",
+			fields:   []string{"id", "name"},
+			want:     map[string]int{"id": 1, "name": 2},
+			wantLock: map[string]int{"id": 1, "name": 2},
+		},
+		{
+			name:         "dropped and added columns",
+			locked:       map[string]int{"id": 1, "name": 2, "age": 3},
+			fields:       []string{"id", "phone", "age"},
+			want:         map[string]int{"id": 1, "phone": 4, "age": 3},
+			wantTags:     "2",
+			wantReserved: `"name"`,
+			wantLock:     map[string]int{"id": 1, "name": 2, "age": 3, "phone": 4},
+		},
+		{
+			name:     "merged file numbers",
+			previous: "message Users {\n  string name = 1;\n  string id = 2;\n}\n",
+			fields:   []string{"id", "name", "phone"},
+			want:     map[string]int{"id": 2, "name": 1, "phone": 3},
+			wantLock: map[string]int{"id": 2, "name": 1, "phone": 3},
+		},
+		{
+			name:         "merged file reserved",
+			previous:     "message Users {\n  reserved 3, 5 to 7;\n  reserved \"b\";\n  string a = 2;\n}\n",
+			fields:       []string{"a", "phone"},
+			want:         map[string]int{"a": 2, "phone": 8},
+			wantTags:     "3, 5 to 7",
+			wantReserved: `"b"`,
+			wantLock:     map[string]int{"a": 2, "phone": 8},
+		},
+		{
+			name:     "field added by hand",
+			locked:   map[string]int{"id": 1, "name": 2},
+			previous: "message Users {\n  string id = 1;\n  string name = 2;\n  string extra = 10;\n}\n",
+			fields:   []string{"id", "name", "phone"},
+			want:     map[string]int{"id": 1, "name": 2, "phone": 11},
+			wantLock: map[string]int{"id": 1, "name": 2, "phone": 11},
+		},
+		{
+			name:     "reserved field added back",
+			previous: "message Users {\n  reserved 2;\n  reserved \"name\";\n  string id = 1;\n}\n",
+			locked:   map[string]int{"id": 1, "name": 2},
+			fields:   []string{"id", "name"},
+			want:     map[string]int{"id": 1, "name": 2},
+			wantLock: map[string]int{"id": 1, "name": 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Lock{Messages: map[string]map[string]int{}}
+			if nil != tt.locked {
+				l.Messages["Users"] = tt.locked
+			}
+			if "" != tt.previous {
+				if err := l.ReadProto(strings.NewReader(tt.previous)); nil != err {
+					t.Fatal(err)
+				}
+			}
+
+			msg := lockMessage(tt.fields...)
+			l.apply(msg)
+			if got := fieldTags(msg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tags = %v, want %v", got, tt.want)
+			}
+			if got := msg.ReservedTags(); got != tt.wantTags {
+				t.Errorf("ReservedTags() = %q, want %q", got, tt.wantTags)
+			}
+			if got := msg.ReservedNames(); got != tt.wantReserved {
+				t.Errorf("ReservedNames() = %q, want %q", got, tt.wantReserved)
+			}
+			if got := l.Messages["Users"]; !reflect.DeepEqual(got, tt.wantLock) {
+				t.Errorf("lock = %v, want %v", got, tt.wantLock)
+			}
+		})
+	}
+}
+
+func TestLockApplyNil(t *testing.T) {
+	var l *Lock
+	msg := lockMessage("id", "name")
+	msg.Fields[0].tag, msg.Fields[1].tag = 1, 2
+	l.apply(msg)
+	if got := fieldTags(msg); !reflect.DeepEqual(got, map[string]int{"id": 1, "name": 2}) {
+		t.Errorf("tags = %v", got)
+	}
+}
+
+func orderByEnum(l *Lock, indexed ...string) *Enum {
+	m := &Message{Name: "Users", OrderBy: OrderByMessage}
+	for _, name := range []string{"id", "name", "age", "created_at"} {
+		m.Fields = append(m.Fields, MessageField{Typ: "string", Name: name, Indexed: slices.Contains(indexed, name)})
+	}
+	enum := m.GenOrderByEnum()
+	l.applyEnum(enum)
+
+	return enum
+}
+
+func TestLockApplyEnum(t *testing.T) {
+	l := &Lock{Messages: map[string]map[string]int{}}
+	if got := orderByEnum(l, "id", "created_at").String(); !strings.Contains(got, "USERS_ORDER_BY_FIELD_CREATED_AT = 2;") {
+		t.Fatalf("enum = %s", got)
+	}
+
+	// an index added on name and the one dropped on id keep the other numbers
+	want := "// 排序字段\n" +
+		"enum UsersOrderByField {\n" +
+		"  USERS_ORDER_BY_FIELD_UNSPECIFIED = 0;\n" +
+		"  USERS_ORDER_BY_FIELD_NAME = 3;\n" +
+		"  USERS_ORDER_BY_FIELD_CREATED_AT = 2;\n" +
+		"}\n"
+	if got := orderByEnum(l, "name", "created_at").String(); got != want {
+		t.Errorf("enum = %q, want %q", got, want)
+	}
+	if got, want := l.Enums["UsersOrderByField"]["USERS_ORDER_BY_FIELD_ID"], 1; got != want {
+		t.Errorf("locked id = %d, want %d", got, want)
+	}
+}
+
+func TestLockApplyEnumMerged(t *testing.T) {
+	l := &Lock{Messages: map[string]map[string]int{}}
+	previous := "enum UsersOrderByField {\n  USERS_ORDER_BY_FIELD_UNSPECIFIED = 0;\n  USERS_ORDER_BY_FIELD_CREATED_AT = 5;\n}\n"
+	if err := l.ReadProto(strings.NewReader(previous)); nil != err {
+		t.Fatal(err)
+	}
+
+	enum := orderByEnum(l, "id", "created_at")
+	tags := map[string]int{}
+	for _, f := range enum.Fields {
+		tags[f.Name()] = f.Tag()
+	}
+	want := map[string]int{"USERS_ORDER_BY_FIELD_UNSPECIFIED": 0, "USERS_ORDER_BY_FIELD_ID": 6, "USERS_ORDER_BY_FIELD_CREATED_AT": 5}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %v, want %v", tags, want)
+	}
+}
@@ -0,0 +1,438 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// protoBlock is a top level statement or definition of a protobuf file, with the comments and
+// blank lines leading it.
+type protoBlock struct {
+	kind string
+	name string
+	text string
+	// start and stop are the offsets of the statement in text, after its leading comments
+	start int
+	stop  int
+	// open and close are the offsets of the braces of a definition body in text, -1 without body
+	open  int
+	close int
+}
+
+func (b protoBlock) key() string {
+	return b.kind + " " + b.name
+}
+
+func (b protoBlock) body() string {
+	if b.open < 0 {
+		return ""
+	}
+	return b.text[b.open+1 : b.close]
+}
+
+// signature returns the statement up to its body without white spaces, e.g. the name, request
+// and response of a rpc function.
+func (b protoBlock) signature() string {
+	end := b.stop
+	if b.open >= 0 {
+		end = b.open
+	}
+
+	return strings.Join(strings.Fields(strings.TrimSuffix(strings.TrimSpace(b.text[b.start:end]), ";")), "")
+}
+
+// fieldPattern matches a message field statement, capturing its name and number.
+var fieldPattern = regexp.MustCompile(`^(?:(?:optional|repeated|required)\s+)?(?:map\s*<[^>]*>|[\w.]+)\s+(\w+)\s*=\s*(\d+)`)
+
+// field returns the name and number of a message field statement.
+func (b protoBlock) field() (string, int, bool) {
+	switch b.kind {
+	case "option", "reserved", "extensions", "message", "enum", "oneof", "extend":
+		return "", 0, false
+	}
+	m := fieldPattern.FindStringSubmatch(b.text[b.start:b.stop])
+	if nil == m {
+		return "", 0, false
+	}
+	tag, err := strconv.Atoi(m[2])
+
+	return m[1], tag, nil == err
+}
+
+func isHeader(kind string) bool {
+	switch kind {
+	case "syntax", "edition", "package", "import", "option":
+		return true
+	}
+	return false
+}
+
+type protoScanner struct {
+	src string
+	pos int
+}
+
+// skip skips the white spaces and comments.
+func (s *protoScanner) skip() {
+	for s.pos < len(s.src) {
+		switch {
+		case strings.HasPrefix(s.src[s.pos:], "//"):
+			s.toLineEnd()
+		case strings.HasPrefix(s.src[s.pos:], "/*"):
+			s.toCommentEnd()
+		case strings.ContainsRune(" \t\r\n", rune(s.src[s.pos])):
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (s *protoScanner) toLineEnd() {
+	if i := strings.IndexByte(s.src[s.pos:], '\n'); i >= 0 {
+		s.pos += i
+	} else {
+		s.pos = len(s.src)
+	}
+}
+
+func (s *protoScanner) toCommentEnd() {
+	if i := strings.Index(s.src[s.pos+2:], "*/"); i >= 0 {
+		s.pos += i + 4
+	} else {
+		s.pos = len(s.src)
+	}
+}
+
+func (s *protoScanner) toQuoteEnd() {
+	quote := s.src[s.pos]
+	for s.pos++; s.pos < len(s.src); s.pos++ {
+		switch s.src[s.pos] {
+		case '\\':
+			s.pos++
+		case quote:
+			s.pos++
+			return
+		}
+	}
+}
+
+// word reads the name of a statement, a quoted string or an identifier which may be a
+// parenthesized option name.
+func (s *protoScanner) word() string {
+	start := s.pos
+	if s.pos < len(s.src) && (s.src[s.pos] == '"' || s.src[s.pos] == '\'') {
+		s.toQuoteEnd()
+		return s.src[start:s.pos]
+	}
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case c == '(' && s.pos == start:
+			if i := strings.IndexByte(s.src[s.pos:], ')'); i >= 0 {
+				s.pos += i + 1
+				continue
+			}
+			s.pos = len(s.src)
+		case c == '_' || c == '.' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			s.pos++
+			continue
+		}
+		break
+	}
+	return s.src[start:s.pos]
+}
+
+// end moves after the `;` or the closing brace ending the current statement and returns the
+// offsets of the braces of its body.
+func (s *protoScanner) end() (open, close int, err error) {
+	open, close = -1, -1
+	depth := 0
+	for s.pos < len(s.src) {
+		switch c := s.src[s.pos]; {
+		case strings.HasPrefix(s.src[s.pos:], "//"):
+			s.toLineEnd()
+		case strings.HasPrefix(s.src[s.pos:], "/*"):
+			s.toCommentEnd()
+		case c == '"' || c == '\'':
+			s.toQuoteEnd()
+		case c == '{':
+			if depth == 0 && open < 0 {
+				open = s.pos
+			}
+			depth++
+			s.pos++
+		case c == '}':
+			depth--
+			s.pos++
+			if depth == 0 {
+				close = s.pos - 1
+				// an aggregate option value is followed by `;`
+				for s.pos < len(s.src) && (s.src[s.pos] == ' ' || s.src[s.pos] == '\t') {
+					s.pos++
+				}
+				if s.pos < len(s.src) && s.src[s.pos] == ';' {
+					s.pos++
+				}
+				return open, close, nil
+			}
+		case c == ';' && depth == 0:
+			s.pos++
+			return open, close, nil
+		default:
+			s.pos++
+		}
+	}
+
+	return open, close, errors.New("unexpected end of file")
+}
+
+// lineEnd moves after the rest of the line ending the current statement, e.g. a trailing comment.
+func (s *protoScanner) lineEnd() {
+	pos := s.pos
+	for pos < len(s.src) && (s.src[pos] == ' ' || s.src[pos] == '\t' || s.src[pos] == '\r') {
+		pos++
+	}
+	if strings.HasPrefix(s.src[pos:], "//") {
+		s.pos = pos
+		s.toLineEnd()
+		pos = s.pos
+	}
+	if pos < len(s.src) && s.src[pos] == '\n' {
+		s.pos = pos + 1
+	}
+}
+
+// splitBlocks splits a protobuf file, or the body of a definition, in its statements. the
+// comments and blank lines following the last statement are returned as tail.
+func splitBlocks(src string) (blocks []protoBlock, tail string, err error) {
+	s := &protoScanner{src: src}
+	start := 0
+	for {
+		s.skip()
+		if s.pos >= len(src) {
+			break
+		}
+
+		offset := s.pos
+		kind := s.word()
+		if "" == kind {
+			return nil, "", fmt.Errorf("unexpected `%c` at offset %d", src[s.pos], s.pos)
+		}
+		s.skip()
+		name := s.word()
+		switch kind {
+		case "syntax", "edition", "package":
+			// a file has only one
+			name = ""
+		case "import":
+			if "public" == name || "weak" == name {
+				s.skip()
+				name = s.word()
+			}
+		}
+
+		open, close, err := s.end()
+		if nil != err {
+			return nil, "", errors.Wrapf(err, "`%s %s` at offset %d", kind, name, offset)
+		}
+		stop := s.pos
+		s.lineEnd()
+
+		block := protoBlock{kind: kind, name: name, text: src[start:s.pos], start: offset - start, stop: stop - start, open: -1, close: -1}
+		if open >= 0 {
+			block.open, block.close = open-start, close-start
+		}
+		blocks = append(blocks, block)
+		start = s.pos
+	}
+
+	return blocks, src[start:], nil
+}
+
+// Merge keeps the custom content of the previous protobuf file in the generated one. the
+// messages, enums, rpc functions, imports and options generated again are replaced, the others
+// are custom: imports and options are kept after the generated header, rpc functions at the end
+// of their service and the other definitions at the end of the file, with their comments.
+func Merge(previous, generated string) (string, error) {
+	before, _, err := splitBlocks(previous)
+	if nil != err {
+		return "", errors.Wrap(err, "parse previous proto")
+	}
+	after, tail, err := splitBlocks(generated)
+	if nil != err {
+		return "", errors.Wrap(err, "parse generated proto")
+	}
+
+	generatedBlocks := map[string]protoBlock{}
+	for _, b := range after {
+		generatedBlocks[b.key()] = b
+	}
+
+	// the custom imports and options follow the last generated statement of their kind, or the
+	// generated header
+	anchors := map[string]int{}
+	for i, b := range after {
+		if isHeader(b.kind) {
+			anchors[b.kind] = i
+			anchors[""] = i
+		}
+	}
+
+	var custom []string
+	header := map[int][]string{}
+	merged := map[string]string{}
+	for _, b := range before {
+		g, ok := generatedBlocks[b.key()]
+		switch {
+		case ok && "service" == b.kind:
+			if merged[b.key()], err = mergeService(b, g); nil != err {
+				return "", err
+			}
+		case ok && "message" == b.kind:
+			if merged[b.key()], err = mergeMessage(b, g); nil != err {
+				return "", err
+			}
+		case ok:
+		case isHeader(b.kind):
+			i, ok := anchors[b.kind]
+			if !ok {
+				i = anchors[""]
+			}
+			header[i] = append(header[i], strings.TrimLeft(b.text, "\n"))
+		default:
+			custom = append(custom, b.text)
+		}
+	}
+
+	var out strings.Builder
+	if _, ok := anchors[""]; !ok {
+		out.WriteString(strings.Join(header[0], ""))
+		delete(header, 0)
+	}
+	for i, b := range after {
+		if text, ok := merged[b.key()]; ok {
+			out.WriteString(text)
+		} else {
+			out.WriteString(b.text)
+		}
+		out.WriteString(strings.Join(header[i], ""))
+	}
+	for _, text := range custom {
+		if !strings.HasSuffix(out.String(), "\n") {
+			out.WriteString("\n")
+		}
+		out.WriteString(text)
+	}
+	out.WriteString(tail)
+
+	// ends like the generated file
+	trimmed := strings.TrimRight(generated, "\n")
+	return strings.TrimRight(out.String(), "\n") + generated[len(trimmed):], nil
+}
+
+// mergeService adds the custom rpc functions and options of the previous service at the end of
+// the generated one. a generated rpc function keeps its previous body, e.g. its http options,
+// while its signature is unchanged.
+func mergeService(previous, generated protoBlock) (string, error) {
+	before, _, err := splitBlocks(previous.body())
+	if nil != err {
+		return "", errors.Wrapf(err, "parse previous service `%s`", previous.name)
+	}
+	after, tail, err := splitBlocks(generated.body())
+	if nil != err {
+		return "", errors.Wrapf(err, "parse generated service `%s`", generated.name)
+	}
+
+	previousBlocks := map[string]protoBlock{}
+	for _, b := range before {
+		previousBlocks[b.key()] = b
+	}
+
+	var body strings.Builder
+	generatedBlocks := map[string]bool{}
+	for _, b := range after {
+		generatedBlocks[b.key()] = true
+		if p, ok := previousBlocks[b.key()]; ok && "rpc" == b.kind && p.open >= 0 && p.signature() == b.signature() {
+			body.WriteString(b.text[:b.start] + p.text[p.start:])
+			continue
+		}
+		body.WriteString(b.text)
+	}
+	body.WriteString(tail)
+
+	var custom strings.Builder
+	for i, b := range before {
+		if !generatedBlocks[b.key()] {
+			custom.WriteString(bodyText(before, i))
+		}
+	}
+
+	return withBody(generated, body.String(), custom.String()), nil
+}
+
+// mergeMessage adds the custom fields, options and definitions of the previous message at the end
+// of the generated one. a field is custom when neither its name nor its number is generated, e.g.
+// a field added by hand or the field of a dropped column.
+func mergeMessage(previous, generated protoBlock) (string, error) {
+	before, _, err := splitBlocks(previous.body())
+	if nil != err {
+		return "", errors.Wrapf(err, "parse previous message `%s`", previous.name)
+	}
+	after, _, err := splitBlocks(generated.body())
+	if nil != err {
+		return "", errors.Wrapf(err, "parse generated message `%s`", generated.name)
+	}
+
+	generatedBlocks := map[string]bool{}
+	names := map[string]bool{}
+	tags := map[int]bool{}
+	for _, b := range after {
+		generatedBlocks[b.key()] = true
+		if name, tag, ok := b.field(); ok {
+			names[name], tags[tag] = true, true
+		}
+	}
+
+	var custom strings.Builder
+	for i, b := range before {
+		name, tag, ok := b.field()
+		switch {
+		case ok && !names[name] && !tags[tag]:
+			custom.WriteString(bodyText(before, i))
+		case ok || "reserved" == b.kind:
+			// generated again, the reserved numbers and names are kept by the lock
+		case !generatedBlocks[b.key()]:
+			custom.WriteString(bodyText(before, i))
+		}
+	}
+
+	return withBody(generated, generated.body(), custom.String()), nil
+}
+
+// bodyText returns the text of a block of a definition body, the first one without the end of
+// the line opening the body.
+func bodyText(blocks []protoBlock, i int) string {
+	text := blocks[i].text
+	if 0 == i {
+		if j := strings.IndexByte(text, '\n'); j >= 0 && "" == strings.TrimSpace(text[:j]) {
+			text = text[j+1:]
+		}
+	}
+
+	return text
+}
+
+// withBody returns the generated definition with body, followed by the custom statements.
+func withBody(generated protoBlock, body, custom string) string {
+	if "" == custom {
+		return generated.text[:generated.open+1] + body + generated.text[generated.close:]
+	}
+
+	body = strings.TrimRight(body, "\n") + "\n"
+	return generated.text[:generated.open+1] + body + custom + generated.text[generated.close:]
+}
@@ -0,0 +1,240 @@
+package parser
+
+import (
+	"testing"
+)
+
+const mergeGenerated = `syntax = "proto3";
+
+option go_package ="./pb";
+
+package user;
+
+// 用户
+message Users {
+  // ID
+  int64 id = 1;
+  // 用户名
+  string name = 2;
+}
+
+message GetUsersReq {
+  int64 id = 1;
+}
+
+service User{
+
+	 // 查询用户
+	 rpc GetUsers(GetUsersReq) returns (Users);
+
+	 // 删除用户
+	 rpc DelUsers(GetUsersReq) returns (Users);
+}
+`
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous string
+		want     string
+	}{
+		{
+			name:     "generated",
+			previous: mergeGenerated,
+			want:     mergeGenerated,
+		},
+		{
+			name: "custom definitions",
+			previous: `syntax = "proto3";
+
+option go_package ="./pb";
+option java_multiple_files = true;
+
+package user;
+
+import "google/api/annotations.proto";
+
+// 用户
+message Users {
+  int64 id = 1;
+}
+
+// 地址
+message Address {
+  string city = 1;
+}
+
+service User{
+	 rpc GetUsers(GetUsersReq) returns (Users);
+
+	 // 登录
+	 rpc Login(Address) returns (Users);
+}
+`,
+			want: `syntax = "proto3";
+
+option go_package ="./pb";
+option java_multiple_files = true;
+
+package user;
+import "google/api/annotations.proto";
+
+// 用户
+message Users {
+  // ID
+  int64 id = 1;
+  // 用户名
+  string name = 2;
+}
+
+message GetUsersReq {
+  int64 id = 1;
+}
+
+service User{
+
+	 // 查询用户
+	 rpc GetUsers(GetUsersReq) returns (Users);
+
+	 // 删除用户
+	 rpc DelUsers(GetUsersReq) returns (Users);
+
+	 // 登录
+	 rpc Login(Address) returns (Users);
+}
+
+// 地址
+message Address {
+  string city = 1;
+}
+`,
+		},
+		{
+			name: "custom fields",
+			previous: `syntax = "proto3";
+
+option go_package ="./pb";
+
+package user;
+
+// 用户
+message Users {
+  option deprecated = true;
+  reserved 4;
+  reserved "age";
+  int64 id = 1;
+  string name = 2;
+  // 手写字段
+  string extra = 10;
+  // 同号字段
+  string other = 2;
+}
+`,
+			want: `syntax = "proto3";
+
+option go_package ="./pb";
+
+package user;
+
+// 用户
+message Users {
+  // ID
+  int64 id = 1;
+  // 用户名
+  string name = 2;
+  option deprecated = true;
+  // 手写字段
+  string extra = 10;
+}
+
+message GetUsersReq {
+  int64 id = 1;
+}
+
+service User{
+
+	 // 查询用户
+	 rpc GetUsers(GetUsersReq) returns (Users);
+
+	 // 删除用户
+	 rpc DelUsers(GetUsersReq) returns (Users);
+}
+`,
+		},
+		{
+			name: "rpc options",
+			previous: `syntax = "proto3";
+
+option go_package ="./pb";
+
+package user;
+
+service User{
+	 // 旧注释
+	 rpc GetUsers(GetUsersReq) returns (Users) {
+	   option (google.api.http) = {get: "/users/{id}"};
+	 }
+
+	 rpc DelUsers(Users) returns (Users) {
+	   option (google.api.http) = {delete: "/users/{id}"};
+	 }
+}
+`,
+			want: `syntax = "proto3";
+
+option go_package ="./pb";
+
+package user;
+
+// 用户
+message Users {
+  // ID
+  int64 id = 1;
+  // 用户名
+  string name = 2;
+}
+
+message GetUsersReq {
+  int64 id = 1;
+}
+
+service User{
+
+	 // 查询用户
+	 rpc GetUsers(GetUsersReq) returns (Users) {
+	   option (google.api.http) = {get: "/users/{id}"};
+	 }
+
+	 // 删除用户
+	 rpc DelUsers(GetUsersReq) returns (Users);
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Merge(tt.previous, mergeGenerated)
+			if nil != err {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Merge() = \n%s\nwant\n%s", got, tt.want)
+			}
+
+			// merging again changes nothing
+			again, err := Merge(got, mergeGenerated)
+			if nil != err {
+				t.Fatal(err)
+			}
+			if again != got {
+				t.Errorf("Merge() again = \n%s\nwant\n%s", again, got)
+			}
+		})
+	}
+}
+
+func TestMergeError(t *testing.T) {
+	if _, err := Merge("message Users {\n  int64 id = 1;\n", mergeGenerated); nil == err {
+		t.Error("expected an error for an unterminated message")
+	}
+}
@@ -3,8 +3,11 @@ package parser
 import (
 	"bytes"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/emicklei/proto"
 	"golang.org/x/exp/slices"
 
 	"github.com/ch3nnn/sql2pb/cmd/generation/tools/stringx"
@@ -19,6 +22,37 @@ var (
 	// gen protobuf field style
 	fieldStyleToCamelWithStartLower = "sqlPb"
 	fieldStyleToSnake               = "sql_pb"
+
+	// gen protobuf update request style
+	UpdateStyleOptional  = "optional"
+	UpdateStyleFieldMask = "field_mask"
+
+	// gen protobuf add and update response style
+	RespStyleEmpty  = "empty"
+	RespStyleId     = "id"
+	RespStyleEntity = "entity"
+
+	// gen protobuf list request pagination
+	PaginationOffset = "offset"
+	PaginationCursor = "cursor"
+	PaginationAip158 = "aip158"
+
+	// gen protobuf list request ordering
+	OrderByNone    = "none"
+	OrderByString  = "string"
+	OrderByMessage = "message"
+
+	// gen protobuf filter message style
+	FilterStyleEqual    = "equal"
+	FilterStyleOperator = "operator"
+
+	// gen rpc operations
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+	OpGet    = "get"
+	OpList   = "list"
+	Ops      = []string{OpCreate, OpUpdate, OpDelete, OpGet, OpList}
 )
 
 type Message struct {
@@ -26,17 +60,72 @@ type Message struct {
 	Comment string
 	Fields  []MessageField
 	Style   string
+	// VersionColumn is the optimistic locking column. when set it is kept in the
+	// default message and required by the update and delete requests.
+	VersionColumn string
+	// UpdateStyle is the update request style. optional | field_mask
+	UpdateStyle string
+	// RespStyle is the add and update response style. empty | id | entity
+	RespStyle string
+	// Pagination is the list request pagination. offset | cursor | aip158
+	Pagination string
+	// OrderBy is the list request ordering. none | string | message
+	OrderBy string
+	// FilterStyle is the filter message style. equal | operator
+	FilterStyle string
+	// Batch enables the batch insert, update, delete and get messages
+	Batch bool
+	// Stream enables the server streaming export message
+	Stream bool
+	// Ops is the rpc operations to generate, nil generates every operation and empty none
+	Ops []string
+	// Naming is the naming convention of the rpc functions and their messages
+	Naming Naming
+	// Plural is the plural of the message name used by the list naming
+	Plural string
+	// Catalog is the text of the generated comments
+	Catalog Catalog
+	// Lock keeps the field numbers of the generated messages, nil numbers the fields in order
+	Lock *Lock
+	// Reserved is the fields of dropped columns whose number and name must not be reused
+	Reserved []MessageField
+
+	// reservedRanges and reservedNames are reserved by the protobuf file merged into
+	reservedRanges []proto.Range
+	reservedNames  []string
+}
+
+// GenMessages gen every message of the table in output order
+func (m *Message) GenMessages() []*Message {
+	var msgs []*Message
+	msgs = append(msgs, m.GenDefaultMessage()...)
+	msgs = append(msgs, m.GenDefaultFilterMessage()...)
+	msgs = append(msgs, m.GenRpcAddReqRespMessage()...)
+	msgs = append(msgs, m.GenRpcUpdateReqMessage()...)
+	msgs = append(msgs, m.GenRpcDelReqMessage()...)
+	msgs = append(msgs, m.GenRpcGetByIdReqMessage()...)
+	msgs = append(msgs, m.GenOrderByMessage()...)
+	msgs = append(msgs, m.GenRpcSearchReqMessage()...)
+	msgs = append(msgs, m.GenRpcBatchReqRespMessage()...)
+	msgs = append(msgs, m.GenRpcExportReqMessage()...)
+
+	for _, msg := range msgs {
+		m.Lock.apply(msg)
+	}
+
+	return msgs
 }
 
 // GenDefaultMessage gen default message
-func (m *Message) GenDefaultMessage(buf *bytes.Buffer) {
+func (m *Message) GenDefaultMessage() []*Message {
+	var msgs []*Message
 	mOrginName := m.Name
 	mOrginFields := m.Fields
 
 	var curFields []MessageField
 	var filedTag int
 	for _, field := range m.Fields {
-		if slices.Contains([]string{"version", "del_state", "delete_time"}, field.Name) {
+		if slices.Contains([]string{"version", "del_state", "delete_time"}, field.column()) && !m.isVersionField(field) {
 			continue
 		}
 		filedTag++
@@ -46,20 +135,26 @@ func (m *Message) GenDefaultMessage(buf *bytes.Buffer) {
 			field.Name = stringx.From(field.Name).ToSnake()
 		}
 
-		if field.Comment == "" {
-			field.Comment = field.Name
-		}
 		curFields = append(curFields, field)
 	}
 	m.Fields = curFields
-	buf.WriteString(fmt.Sprintf("%s\n", m))
+	msg := m.snapshot()
+	msg.Comment = m.Comment
+	msgs = append(msgs, msg)
 
 	// reset
 	m.Name = mOrginName
 	m.Fields = mOrginFields
+
+	return msgs
 }
 
-func (m *Message) GenDefaultFilterMessage(buf *bytes.Buffer) {
+func (m *Message) GenDefaultFilterMessage() []*Message {
+	if !m.HasOp(OpList) {
+		return nil
+	}
+
+	var msgs []*Message
 	mOrginName := m.Name
 	mOrginFields := m.Fields
 
@@ -67,7 +162,7 @@ func (m *Message) GenDefaultFilterMessage(buf *bytes.Buffer) {
 	var curFields []MessageField
 	var filedTag int
 	for _, field := range m.Fields {
-		if slices.Contains([]string{"version", "del_state", "delete_time"}, field.Name) {
+		if !m.isFilterField(field) {
 			continue
 		}
 		filedTag++
@@ -77,33 +172,42 @@ func (m *Message) GenDefaultFilterMessage(buf *bytes.Buffer) {
 			field.Name = stringx.From(field.Name).ToSnake()
 		}
 
-		if field.Comment == "" {
-			field.Comment = field.Name
+		if m.FilterStyle == FilterStyleOperator {
+			field.Typ = filterOperatorName(field.Typ, field.Nullable)
+		} else {
+			// 可选
+			field.Typ = "optional " + field.Typ
 		}
-		// 可选
-		field.Typ = "optional " + field.Typ
 
 		curFields = append(curFields, field)
 	}
 	m.Fields = curFields
-	buf.WriteString(fmt.Sprintf("%s\n", m))
+	msgs = append(msgs, m.snapshot())
 
 	// reset
 	m.Name = mOrginName
 	m.Fields = mOrginFields
+
+	return msgs
 }
 
 // GenRpcAddReqRespMessage gen add req message
-func (m *Message) GenRpcAddReqRespMessage(buf *bytes.Buffer) {
+func (m *Message) GenRpcAddReqRespMessage() []*Message {
+	if !m.HasOp(OpCreate) {
+		return nil
+	}
+
+	var msgs []*Message
 	mOrginName := m.Name
 	mOrginFields := m.Fields
+	entity := m.entityField(1)
 
 	// req
-	m.Name = "Add" + mOrginName + "Req"
+	m.Name = m.Naming.Request(m.Naming.Create, mOrginName, m.Plural)
 	var curFields []MessageField
 	var filedTag int
 	for _, field := range m.Fields {
-		if slices.Contains([]string{"id", "create_at", "create_time", "update_time", "update_at", "version", "del_state", "delete_time", "delete_at"}, field.Name) {
+		if slices.Contains([]string{"id", "create_at", "create_time", "update_time", "update_at", "version", "del_state", "delete_time", "delete_at"}, field.column()) || m.isVersionField(field) {
 			continue
 		}
 		filedTag++
@@ -112,39 +216,51 @@ func (m *Message) GenRpcAddReqRespMessage(buf *bytes.Buffer) {
 		if m.Style == fieldStyleToSnake {
 			field.Name = stringx.From(field.Name).ToSnake()
 		}
-		if field.Comment == "" {
-			field.Comment = field.Name
-		}
 		curFields = append(curFields, field)
 	}
 	m.Fields = curFields
-	buf.WriteString(fmt.Sprintf("%s\n", m))
+	msgs = append(msgs, m.snapshot())
 
 	// reset
 	m.Name = mOrginName
 	m.Fields = mOrginFields
 
 	// resp
-	m.Name = "Add" + mOrginName + "Resp"
-	m.Fields = []MessageField{}
-	buf.WriteString(fmt.Sprintf("%s\n", m))
+	m.Name = m.Naming.Response(m.Naming.Create, mOrginName, m.Plural)
+	switch m.RespStyle {
+	case RespStyleId:
+		m.Fields = []MessageField{m.idField(1)}
+	case RespStyleEntity:
+		m.Fields = []MessageField{entity}
+	default:
+		m.Fields = []MessageField{}
+	}
+	msgs = append(msgs, m.snapshot())
 
 	// reset
 	m.Name = mOrginName
 	m.Fields = mOrginFields
 
+	return msgs
 }
 
 // GenRpcUpdateReqMessage gen add resp message
-func (m *Message) GenRpcUpdateReqMessage(buf *bytes.Buffer) {
+func (m *Message) GenRpcUpdateReqMessage() []*Message {
+	if !m.HasOp(OpUpdate) {
+		return nil
+	}
+
+	var msgs []*Message
 	mOrginName := m.Name
 	mOrginFields := m.Fields
+	entity := m.entityField(1)
 
-	m.Name = "Update" + mOrginName + "Req"
+	m.Name = m.Naming.Request(m.Naming.Update, mOrginName, m.Plural)
 	var curFields []MessageField
 	var filedTag int
 	for _, field := range m.Fields {
-		if slices.Contains([]string{"create_time", "create_at", "update_time", "update_at", "version", "del_state", "delete_time", "delete_at"}, field.Name) {
+		isVersion := m.isVersionField(field)
+		if slices.Contains([]string{"create_time", "create_at", "update_time", "update_at", "version", "del_state", "delete_time", "delete_at"}, field.column()) && !isVersion {
 			continue
 		}
 		filedTag++
@@ -153,66 +269,95 @@ func (m *Message) GenRpcUpdateReqMessage(buf *bytes.Buffer) {
 		if m.Style == fieldStyleToSnake {
 			field.Name = stringx.From(field.Name).ToSnake()
 		}
-		if field.Comment == "" {
-			field.Comment = field.Name
+		// 可选, 乐观锁版本号必填
+		if !isVersion {
+			field.Typ = "optional " + field.Typ
 		}
-		// 可选
-		field.Typ = "optional " + field.Typ
 
 		curFields = append(curFields, field)
 	}
 	m.Fields = curFields
-	buf.WriteString(fmt.Sprintf("%s\n", m))
+
+	// AIP-134: the whole resource with a field mask of the columns to set
+	if m.UpdateStyle == UpdateStyleFieldMask {
+		maskName := "update_mask"
+		if m.Style != fieldStyleToSnake {
+			maskName = stringx.From(maskName).ToCamelWithStartLower()
+		}
+		m.Fields = []MessageField{
+			entity,
+			{Typ: "google.protobuf.FieldMask", Name: maskName, tag: 2, Comment: m.Catalog.T("field.update_mask")},
+		}
+	}
+	msgs = append(msgs, m.snapshot())
 
 	// reset
 	m.Name = mOrginName
 	m.Fields = mOrginFields
 
 	// resp
-	m.Name = "Update" + mOrginName + "Resp"
-	m.Fields = []MessageField{}
-	buf.WriteString(fmt.Sprintf("%s\n", m))
+	m.Name = m.Naming.Response(m.Naming.Update, mOrginName, m.Plural)
+	switch m.RespStyle {
+	case RespStyleId:
+		m.Fields = append([]MessageField{m.idField(1)}, m.versionFields(2)...)
+	case RespStyleEntity:
+		// the entity already carries the version column
+		m.Fields = []MessageField{entity}
+	default:
+		m.Fields = m.versionFields(1)
+	}
+	msgs = append(msgs, m.snapshot())
 
 	// reset
 	m.Name = mOrginName
 	m.Fields = mOrginFields
+
+	return msgs
 }
 
 // GenRpcDelReqMessage gen add resp message
-func (m *Message) GenRpcDelReqMessage(buf *bytes.Buffer) {
+func (m *Message) GenRpcDelReqMessage() []*Message {
+	if !m.HasOp(OpDelete) {
+		return nil
+	}
+
+	var msgs []*Message
 	mOrginName := m.Name
 	mOrginFields := m.Fields
 
-	m.Name = "Del" + mOrginName + "Req"
-	m.Fields = []MessageField{
-		{Name: "id", Typ: "int64", tag: 1, Comment: "id"},
-	}
-	buf.WriteString(fmt.Sprintf("%s\n", m))
+	m.Name = m.Naming.Request(m.Naming.Delete, mOrginName, m.Plural)
+	m.Fields = append([]MessageField{m.idField(1)}, m.versionFields(2)...)
+	msgs = append(msgs, m.snapshot())
 
 	// reset
 	m.Name = mOrginName
 	m.Fields = mOrginFields
 
 	// resp
-	m.Name = "Del" + mOrginName + "Resp"
-	m.Fields = []MessageField{}
-	buf.WriteString(fmt.Sprintf("%s\n", m))
+	m.Name = m.Naming.Response(m.Naming.Delete, mOrginName, m.Plural)
+	m.Fields = m.versionFields(1)
+	msgs = append(msgs, m.snapshot())
 
 	// reset
 	m.Name = mOrginName
 	m.Fields = mOrginFields
+
+	return msgs
 }
 
 // GenRpcGetByIdReqMessage gen add resp message
-func (m *Message) GenRpcGetByIdReqMessage(buf *bytes.Buffer) {
+func (m *Message) GenRpcGetByIdReqMessage() []*Message {
+	if !m.HasOp(OpGet) {
+		return nil
+	}
+
+	var msgs []*Message
 	mOrginName := m.Name
 	mOrginFields := m.Fields
 
-	m.Name = "Select" + mOrginName + "ByIdReq"
-	m.Fields = []MessageField{
-		{Name: "id", Typ: "int64", tag: 1, Comment: "id"},
-	}
-	buf.WriteString(fmt.Sprintf("%s\n", m))
+	m.Name = m.Naming.Request(m.Naming.Get, mOrginName, m.Plural)
+	m.Fields = []MessageField{m.idField(1)}
+	msgs = append(msgs, m.snapshot())
 
 	// reset
 	m.Name = mOrginName
@@ -220,7 +365,7 @@ func (m *Message) GenRpcGetByIdReqMessage(buf *bytes.Buffer) {
 
 	// resp
 	firstWord := strings.ToLower(string(m.Name[0]))
-	m.Name = "Select" + mOrginName + "ByIdResp"
+	m.Name = m.Naming.Response(m.Naming.Get, mOrginName, m.Plural)
 
 	name := stringx.From(firstWord + mOrginName[1:]).ToCamelWithStartLower()
 	comment := stringx.From(firstWord + mOrginName[1:]).ToCamelWithStartLower()
@@ -231,26 +376,112 @@ func (m *Message) GenRpcGetByIdReqMessage(buf *bytes.Buffer) {
 	m.Fields = []MessageField{
 		{Typ: mOrginName, Name: name, tag: 1, Comment: comment},
 	}
-	buf.WriteString(fmt.Sprintf("%s\n", m))
+	msgs = append(msgs, m.snapshot())
 
 	// reset
 	m.Name = mOrginName
 	m.Fields = mOrginFields
+
+	return msgs
 }
 
-// GenRpcSearchReqMessage gen add resp message
-func (m *Message) GenRpcSearchReqMessage(buf *bytes.Buffer) {
+// GenOrderByEnum gen list order by enum of the sortable columns
+func (m *Message) GenOrderByEnum() *Enum {
+	if m.OrderBy != OrderByMessage || !m.HasOp(OpList) {
+		return nil
+	}
+
+	// only indexed columns are sortable, fall back to every column when the index is unknown
+	var columns []string
+	for _, field := range m.Fields {
+		if field.Indexed {
+			columns = append(columns, field.Name)
+		}
+	}
+	if len(columns) == 0 {
+		for _, field := range m.Fields {
+			columns = append(columns, field.Name)
+		}
+	}
+
+	// enum values share the package scope, so prefix them with the enum name
+	enumName := m.Name + "OrderByField"
+	prefix := stringx.From(enumName).ToSnake()
+	enum := &Enum{Name: enumName, Comment: m.Catalog.T("enum.order_by", m.Comment)}
+	enum.Fields = append(enum.Fields, NewEnumField(prefix+"_unspecified", 0))
+	for i, column := range columns {
+		enum.Fields = append(enum.Fields, NewEnumField(prefix+"_"+column, i+1))
+	}
+
+	return enum
+}
+
+// GenOrderByMessage gen list order by message
+func (m *Message) GenOrderByMessage() []*Message {
+	if m.OrderBy != OrderByMessage || !m.HasOp(OpList) {
+		return nil
+	}
+
+	var msgs []*Message
 	mOrginName := m.Name
 	mOrginFields := m.Fields
 
-	m.Name = "Select" + mOrginName + "ListReq"
+	enumName := mOrginName + "OrderByField"
+	m.Name = mOrginName + "OrderBy"
 	m.Fields = []MessageField{
-		{Typ: "int64", Name: "page", tag: 1, Comment: "页码"},
-		{Typ: "int64", Name: "page_size", tag: 2, Comment: "每页数量"},
-		{Typ: "optional " + mOrginName + "Filter", Name: "filter", tag: 3, Comment: mOrginName + "Filter"},
+		{Typ: enumName, Name: "field", tag: 1, Comment: m.Catalog.T("field.order_by_field")},
+		{Typ: "bool", Name: "desc", tag: 2, Comment: m.Catalog.T("field.desc")},
 	}
+	msgs = append(msgs, m.snapshot())
+
+	// reset
+	m.Name = mOrginName
+	m.Fields = mOrginFields
+
+	return msgs
+}
 
-	buf.WriteString(fmt.Sprintf("%s\n", m))
+// GenRpcSearchReqMessage gen add resp message
+func (m *Message) GenRpcSearchReqMessage() []*Message {
+	if !m.HasOp(OpList) {
+		return nil
+	}
+
+	var msgs []*Message
+	mOrginName := m.Name
+	mOrginFields := m.Fields
+
+	m.Name = m.Naming.Request(m.Naming.List, mOrginName, m.Plural)
+	switch m.Pagination {
+	case PaginationCursor:
+		m.Fields = []MessageField{
+			{Typ: "int64", Name: "page_size", tag: 1, Comment: m.Catalog.T("field.page_size")},
+			{Typ: "string", Name: "page_token", tag: 2, Comment: m.Catalog.T("field.page_token")},
+			{Typ: "optional " + mOrginName + "Filter", Name: "filter", tag: 3, Comment: mOrginName + "Filter"},
+		}
+	case PaginationAip158:
+		m.Fields = []MessageField{
+			{Typ: "int32", Name: "page_size", tag: 1, Comment: m.Catalog.T("field.page_size")},
+			{Typ: "string", Name: "page_token", tag: 2, Comment: m.Catalog.T("field.page_token")},
+			{Typ: "optional " + mOrginName + "Filter", Name: "filter", tag: 3, Comment: mOrginName + "Filter"},
+		}
+	default:
+		m.Fields = []MessageField{
+			{Typ: "int64", Name: "page", tag: 1, Comment: m.Catalog.T("field.page")},
+			{Typ: "int64", Name: "page_size", tag: 2, Comment: m.Catalog.T("field.page_size")},
+			{Typ: "optional " + mOrginName + "Filter", Name: "filter", tag: 3, Comment: mOrginName + "Filter"},
+		}
+	}
+
+	switch m.OrderBy {
+	case OrderByString:
+		// AIP-132: comma separated columns, e.g. "create_at desc, id"
+		m.Fields = append(m.Fields, MessageField{Typ: "string", Name: "order_by", tag: 4, Comment: m.Catalog.T("field.order_by")})
+	case OrderByMessage:
+		m.Fields = append(m.Fields, MessageField{Typ: "repeated " + mOrginName + "OrderBy", Name: "order_by", tag: 4, Comment: mOrginName + "OrderBy"})
+	}
+
+	msgs = append(msgs, m.snapshot())
 
 	// reset
 	m.Name = mOrginName
@@ -258,7 +489,7 @@ func (m *Message) GenRpcSearchReqMessage(buf *bytes.Buffer) {
 
 	// resp
 	firstWord := strings.ToLower(string(m.Name[0]))
-	m.Name = "Select" + mOrginName + "ListResp"
+	m.Name = m.Naming.Response(m.Naming.List, mOrginName, m.Plural)
 
 	// name := stringx.From(firstWord + mOrginName[1:]).ToCamelWithStartLower()
 	comment := stringx.From(firstWord + mOrginName[1:]).ToCamelWithStartLower()
@@ -267,25 +498,253 @@ func (m *Message) GenRpcSearchReqMessage(buf *bytes.Buffer) {
 		comment = stringx.From(firstWord + mOrginName[1:]).ToSnake()
 	}
 
+	switch m.Pagination {
+	case PaginationCursor:
+		m.Fields = []MessageField{
+			{Typ: "repeated " + mOrginName, Name: "results", tag: 1, Comment: comment},
+			{Typ: "string", Name: "next_page_token", tag: 2, Comment: m.Catalog.T("field.next_page_token")},
+			{Typ: "optional int64", Name: "total_size", tag: 3, Comment: m.Catalog.T("field.total_size")},
+		}
+	case PaginationAip158:
+		// AIP-158: the repeated field is named after the plural resource
+		plural := stringx.From(stringx.From(m.Plural).Untitle()).ToSnake()
+		m.Fields = []MessageField{
+			{Typ: "repeated " + mOrginName, Name: plural, tag: 1, Comment: comment},
+			{Typ: "string", Name: "next_page_token", tag: 2, Comment: m.Catalog.T("field.next_page_token")},
+			{Typ: "int32", Name: "total_size", tag: 3, Comment: m.Catalog.T("field.total_size")},
+		}
+	default:
+		m.Fields = []MessageField{
+			{Typ: "int64", Name: "count", tag: 1, Comment: m.Catalog.T("field.count")},
+			{Typ: "int64", Name: "page_count", tag: 2, Comment: m.Catalog.T("field.page_count")},
+			{Typ: "repeated " + mOrginName, Name: "results", tag: 3, Comment: comment},
+		}
+	}
+	msgs = append(msgs, m.snapshot())
+
+	// reset
+	m.Name = mOrginName
+	m.Fields = mOrginFields
+
+	return msgs
+}
+
+// GenRpcBatchReqRespMessage gen batch add, update, del and get by id messages
+func (m *Message) GenRpcBatchReqRespMessage() []*Message {
+	if !m.Batch {
+		return nil
+	}
+
+	var msgs []*Message
+	mOrginName := m.Name
+	mOrginFields := m.Fields
+
+	// batch add, update and del wrap the single row messages
+	for _, op := range []struct {
+		op           string
+		single, many NamingOp
+	}{
+		{OpCreate, m.Naming.Create, m.Naming.BatchCreate},
+		{OpUpdate, m.Naming.Update, m.Naming.BatchUpdate},
+		{OpDelete, m.Naming.Delete, m.Naming.BatchDelete},
+	} {
+		if !m.HasOp(op.op) {
+			continue
+		}
+		req := m.Naming.Request(op.single, mOrginName, m.Plural)
+		m.Name = m.Naming.Request(op.many, mOrginName, m.Plural)
+		m.Fields = []MessageField{
+			{Typ: "repeated " + req, Name: "items", tag: 1, Comment: req},
+		}
+		msgs = append(msgs, m.snapshot())
+
+		m.Name = m.Naming.Response(op.many, mOrginName, m.Plural)
+		m.Fields = []MessageField{
+			{Typ: "repeated " + m.Naming.Response(op.single, mOrginName, m.Plural), Name: "results", tag: 1, Comment: m.Catalog.T("field.results")},
+		}
+		msgs = append(msgs, m.snapshot())
+	}
+
+	// reset
+	m.Name = mOrginName
+	m.Fields = mOrginFields
+
+	// batch get
+	if !m.HasOp(OpGet) {
+		return msgs
+	}
+	id := m.idField(1)
+	comment := stringx.From(mOrginName).Untitle()
+	if m.Style == fieldStyleToSnake {
+		comment = stringx.From(comment).ToSnake()
+	}
+	m.Name = m.Naming.Request(m.Naming.BatchGet, mOrginName, m.Plural)
+	m.Fields = []MessageField{
+		{Typ: "repeated " + id.Typ, Name: "ids", tag: 1, Comment: "ids"},
+	}
+	msgs = append(msgs, m.snapshot())
+
+	m.Name = m.Naming.Response(m.Naming.BatchGet, mOrginName, m.Plural)
 	m.Fields = []MessageField{
-		{Typ: "int64", Name: "count", tag: 1, Comment: "总数"},
-		{Typ: "int64", Name: "page_count", tag: 2, Comment: "页码总数"},
-		{Typ: "repeated " + mOrginName, Name: "results", tag: 3, Comment: comment},
+		{Typ: "repeated " + mOrginName, Name: "results", tag: 1, Comment: comment},
 	}
-	buf.WriteString(fmt.Sprintf("%s\n", m))
+	msgs = append(msgs, m.snapshot())
 
 	// reset
 	m.Name = mOrginName
 	m.Fields = mOrginFields
+
+	return msgs
+}
+
+// GenRpcExportReqMessage gen server streaming export req message, the rows are streamed as the default message
+func (m *Message) GenRpcExportReqMessage() []*Message {
+	if !m.Stream || !m.HasOp(OpList) {
+		return nil
+	}
+
+	var msgs []*Message
+	mOrginName := m.Name
+	mOrginFields := m.Fields
+
+	m.Name = m.Naming.Request(m.Naming.Export, mOrginName, m.Plural)
+	m.Fields = []MessageField{
+		{Typ: "optional " + mOrginName + "Filter", Name: "filter", tag: 1, Comment: mOrginName + "Filter"},
+	}
+	switch m.OrderBy {
+	case OrderByString:
+		m.Fields = append(m.Fields, MessageField{Typ: "string", Name: "order_by", tag: 2, Comment: m.Catalog.T("field.order_by")})
+	case OrderByMessage:
+		m.Fields = append(m.Fields, MessageField{Typ: "repeated " + mOrginName + "OrderBy", Name: "order_by", tag: 2, Comment: mOrginName + "OrderBy"})
+	}
+	msgs = append(msgs, m.snapshot())
+
+	// reset
+	m.Name = mOrginName
+	m.Fields = mOrginFields
+
+	return msgs
+}
+
+// HasOp reports whether the rpc operation is generated for the message.
+func (m *Message) HasOp(op string) bool {
+	return nil == m.Ops || slices.Contains(m.Ops, op)
+}
+
+// isFilterField reports whether the field is part of the filter message.
+func (m *Message) isFilterField(field MessageField) bool {
+	return !slices.Contains([]string{"version", "del_state", "delete_time"}, field.column()) && !m.isVersionField(field)
+}
+
+// isVersionField reports whether the field is the configured optimistic locking column.
+func (m *Message) isVersionField(field MessageField) bool {
+	return m.VersionColumn != "" && field.column() == m.VersionColumn
+}
+
+// versionFields returns the optimistic locking field tagged with tag, or no
+// field when the message has no version column.
+func (m *Message) versionFields(tag int) []MessageField {
+	for _, field := range m.Fields {
+		if !m.isVersionField(field) {
+			continue
+		}
+		field.tag = tag
+		field.Name = stringx.From(field.Name).ToCamelWithStartLower()
+		if m.Style == fieldStyleToSnake {
+			field.Name = stringx.From(field.Name).ToSnake()
+		}
+		return []MessageField{field}
+	}
+
+	return []MessageField{}
+}
+
+// idField returns the primary key field tagged with tag, the first column of a composite key.
+// the id column is used when the key is unknown and the key defaults to int64 without it.
+func (m *Message) idField(tag int) MessageField {
+	i := slices.IndexFunc(m.Fields, func(f MessageField) bool { return f.Primary })
+	if i < 0 {
+		i = slices.IndexFunc(m.Fields, func(f MessageField) bool { return "id" == f.column() })
+	}
+	if i < 0 {
+		return MessageField{Name: "id", Typ: "int64", tag: tag, Comment: "id"}
+	}
+
+	field := m.Fields[i]
+	field.tag = tag
+	field.Name = stringx.From(field.Name).ToCamelWithStartLower()
+	if m.Style == fieldStyleToSnake {
+		field.Name = stringx.From(field.Name).ToSnake()
+	}
+
+	return field
+}
+
+// entityField returns a field holding the whole message tagged with tag.
+func (m *Message) entityField(tag int) MessageField {
+	name := stringx.From(m.Name).Untitle()
+	if m.Style == fieldStyleToSnake {
+		name = stringx.From(name).ToSnake()
+	}
+
+	return MessageField{Typ: m.Name, Name: name, tag: tag, Comment: name}
+}
+
+// snapshot returns a copy of the message name and fields.
+func (m *Message) snapshot() *Message {
+	return &Message{Name: m.Name, Fields: m.Fields}
+}
+
+// ReservedTags returns the comma separated numbers and ranges of the reserved fields.
+func (m *Message) ReservedTags() string {
+	ranges := append([]proto.Range{}, m.reservedRanges...)
+	for _, f := range m.Reserved {
+		ranges = append(ranges, proto.Range{From: f.tag, To: f.tag})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].From < ranges[j].From
+	})
+
+	var tags []string
+	for _, r := range ranges {
+		if tag := r.SourceRepresentation(); !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	return strings.Join(tags, ", ")
+}
+
+// ReservedNames returns the comma separated quoted names of the reserved fields.
+func (m *Message) ReservedNames() string {
+	var names []string
+	for _, f := range m.Reserved {
+		names = append(names, strconv.Quote(f.Name))
+	}
+	for _, name := range m.reservedNames {
+		if name = strconv.Quote(name); !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	return strings.Join(names, ", ")
 }
 
 // String returns a string representation of a Message.
 func (m *Message) String() string {
 	var buf bytes.Buffer
 
+	buf.WriteString(Comment("", m.Comment))
 	buf.WriteString(fmt.Sprintf("message %s {\n", m.Name))
+	if tags := m.ReservedTags(); "" != tags {
+		buf.WriteString(fmt.Sprintf("%sreserved %s;\n", indent, tags))
+	}
+	if names := m.ReservedNames(); "" != names {
+		buf.WriteString(fmt.Sprintf("%sreserved %s;\n", indent, names))
+	}
 	for _, f := range m.Fields {
-		buf.WriteString(fmt.Sprintf("%s%s; //%s\n", indent, f, f.Comment))
+		buf.WriteString(Comment(indent, f.Comment))
+		buf.WriteString(fmt.Sprintf("%s%s;\n", indent, f))
 	}
 	buf.WriteString("}\n")
 
@@ -310,11 +769,19 @@ type MessageField struct {
 	Name    string
 	tag     int
 	Comment string
+	// Indexed reports whether the column is part of an index
+	Indexed bool
+	// Primary reports whether the column is part of the primary key
+	Primary bool
+	// Column is the database column of the field, which may be renamed
+	Column string
+	// Nullable reports whether the column accepts null
+	Nullable bool
 }
 
 // NewMessageField creates a new message field.
 func NewMessageField(typ, name string, tag int, comment string) MessageField {
-	return MessageField{typ, name, tag, comment}
+	return MessageField{Typ: typ, Name: name, tag: tag, Comment: comment}
 }
 
 // Tag returns the unique numbered tag of the message field.
@@ -322,6 +789,15 @@ func (f MessageField) Tag() int {
 	return f.tag
 }
 
+// column returns the database column of the field, its name when the field is not a column.
+func (f MessageField) column() string {
+	if "" == f.Column {
+		return f.Name
+	}
+
+	return f.Column
+}
+
 // String returns a string representation of a message field.
 func (f MessageField) String() string {
 	return fmt.Sprintf("%s %s = %d", f.Typ, f.Name, f.tag)
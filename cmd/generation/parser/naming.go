@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	// gen rpc and message naming scheme
+	NamingSchemeGoctl = "goctl"
+	NamingSchemeAip   = "aip"
+)
+
+// NamingOp is the naming of an rpc operation. `%s` in the rpc and message names is replaced by
+// the table message name and `%p` by its plural, the message name is suffixed with the request
+// and response suffix.
+type NamingOp struct {
+	Rpc     string `yaml:"rpc"`
+	Message string `yaml:"message"`
+}
+
+// Naming is the naming convention applied to the rpc functions and their messages.
+type Naming struct {
+	RequestSuffix  string   `yaml:"request_suffix"`
+	ResponseSuffix string   `yaml:"response_suffix"`
+	Create         NamingOp `yaml:"create"`
+	Update         NamingOp `yaml:"update"`
+	Delete         NamingOp `yaml:"delete"`
+	Get            NamingOp `yaml:"get"`
+	List           NamingOp `yaml:"list"`
+	Export         NamingOp `yaml:"export"`
+	BatchCreate    NamingOp `yaml:"batch_create"`
+	BatchUpdate    NamingOp `yaml:"batch_update"`
+	BatchDelete    NamingOp `yaml:"batch_delete"`
+	BatchGet       NamingOp `yaml:"batch_get"`
+}
+
+// NamingGoctl is the default goctl style naming, e.g. rpc InsertUser(AddUserReq) returns (AddUserResp).
+var NamingGoctl = Naming{
+	RequestSuffix:  "Req",
+	ResponseSuffix: "Resp",
+	Create:         NamingOp{Rpc: "Insert%s", Message: "Add%s"},
+	Update:         NamingOp{Rpc: "Update%s", Message: "Update%s"},
+	Delete:         NamingOp{Rpc: "Delete%s", Message: "Del%s"},
+	Get:            NamingOp{Rpc: "Select%sById", Message: "Select%sById"},
+	List:           NamingOp{Rpc: "Select%sList", Message: "Select%sList"},
+	Export:         NamingOp{Rpc: "Export%s", Message: "Export%s"},
+	BatchCreate:    NamingOp{Rpc: "BatchInsert%s", Message: "BatchAdd%s"},
+	BatchUpdate:    NamingOp{Rpc: "BatchUpdate%s", Message: "BatchUpdate%s"},
+	BatchDelete:    NamingOp{Rpc: "BatchDelete%s", Message: "BatchDel%s"},
+	BatchGet:       NamingOp{Rpc: "BatchGet%s", Message: "BatchSelect%sById"},
+}
+
+// NamingAip is the Google AIP style naming, e.g. rpc CreateUser(CreateUserRequest) returns (CreateUserResponse).
+var NamingAip = Naming{
+	RequestSuffix:  "Request",
+	ResponseSuffix: "Response",
+	Create:         NamingOp{Rpc: "Create%s", Message: "Create%s"},
+	Update:         NamingOp{Rpc: "Update%s", Message: "Update%s"},
+	Delete:         NamingOp{Rpc: "Delete%s", Message: "Delete%s"},
+	Get:            NamingOp{Rpc: "Get%s", Message: "Get%s"},
+	List:           NamingOp{Rpc: "List%p", Message: "List%p"},
+	Export:         NamingOp{Rpc: "Export%p", Message: "Export%p"},
+	BatchCreate:    NamingOp{Rpc: "BatchCreate%p", Message: "BatchCreate%p"},
+	BatchUpdate:    NamingOp{Rpc: "BatchUpdate%p", Message: "BatchUpdate%p"},
+	BatchDelete:    NamingOp{Rpc: "BatchDelete%p", Message: "BatchDelete%p"},
+	BatchGet:       NamingOp{Rpc: "BatchGet%p", Message: "BatchGet%p"},
+}
+
+// NewNaming returns the naming scheme overridden by the non-empty names of custom. a custom rpc or
+// message name must contain %s or %p, so the names of every table differ, and is not used by another
+// operation.
+func NewNaming(scheme string, custom Naming) (Naming, error) {
+	var n Naming
+	switch scheme {
+	case "", NamingSchemeGoctl:
+		n = NamingGoctl
+	case NamingSchemeAip:
+		n = NamingAip
+	default:
+		return n, fmt.Errorf("naming `%s` not supported. %s | %s", scheme, NamingSchemeGoctl, NamingSchemeAip)
+	}
+
+	override := func(s *string, o string) {
+		if o != "" {
+			*s = o
+		}
+	}
+	override(&n.RequestSuffix, custom.RequestSuffix)
+	override(&n.ResponseSuffix, custom.ResponseSuffix)
+
+	ops := []struct {
+		key    string
+		op     *NamingOp
+		custom NamingOp
+	}{
+		{"create", &n.Create, custom.Create},
+		{"update", &n.Update, custom.Update},
+		{"delete", &n.Delete, custom.Delete},
+		{"get", &n.Get, custom.Get},
+		{"list", &n.List, custom.List},
+		{"export", &n.Export, custom.Export},
+		{"batch_create", &n.BatchCreate, custom.BatchCreate},
+		{"batch_update", &n.BatchUpdate, custom.BatchUpdate},
+		{"batch_delete", &n.BatchDelete, custom.BatchDelete},
+		{"batch_get", &n.BatchGet, custom.BatchGet},
+	}
+	for _, o := range ops {
+		// a name without the table placeholder is the same for every table
+		for _, name := range []string{o.custom.Rpc, o.custom.Message} {
+			if "" != name && !strings.Contains(name, "%s") && !strings.Contains(name, "%p") {
+				return n, fmt.Errorf("naming `%s` of %s requires %%s or %%p", name, o.key)
+			}
+		}
+		override(&o.op.Rpc, o.custom.Rpc)
+		override(&o.op.Message, o.custom.Message)
+	}
+
+	// two operations named the same gen duplicate definitions
+	rpcs, messages := map[string]string{}, map[string]string{}
+	for _, o := range ops {
+		if key, ok := rpcs[o.op.Rpc]; ok {
+			return n, fmt.Errorf("naming `%s` of %s is already used by %s", o.op.Rpc, o.key, key)
+		}
+		if key, ok := messages[o.op.Message]; ok {
+			return n, fmt.Errorf("naming `%s` of %s is already used by %s", o.op.Message, o.key, key)
+		}
+		rpcs[o.op.Rpc], messages[o.op.Message] = o.key, o.key
+	}
+
+	return n, nil
+}
+
+// RpcName returns the rpc function name of the operation.
+func (op NamingOp) RpcName(name, plural string) string {
+	return format(op.Rpc, name, plural)
+}
+
+// Request returns the request message name of the operation.
+func (n Naming) Request(op NamingOp, name, plural string) string {
+	return format(op.Message, name, plural) + n.RequestSuffix
+}
+
+// Response returns the response message name of the operation.
+func (n Naming) Response(op NamingOp, name, plural string) string {
+	return format(op.Message, name, plural) + n.ResponseSuffix
+}
+
+// format replaces the name and plural placeholders of a naming.
+func format(naming, name, plural string) string {
+	return strings.NewReplacer("%s", name, "%p", plural).Replace(naming)
+}
@@ -0,0 +1,30 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestNewNaming(t *testing.T) {
+	tests := []struct {
+		name    string
+		scheme  string
+		custom  Naming
+		wantErr bool
+	}{
+		{name: "default"},
+		{name: "aip", scheme: NamingSchemeAip},
+		{name: "unknown scheme", scheme: "rest", wantErr: true},
+		{name: "custom", custom: Naming{Get: NamingOp{Rpc: "Fetch%s", Message: "Fetch%s"}, List: NamingOp{Rpc: "All%p"}}},
+		{name: "message without placeholder", custom: Naming{Get: NamingOp{Message: "Get"}}, wantErr: true},
+		{name: "rpc without placeholder", custom: Naming{Delete: NamingOp{Rpc: "Remove"}}, wantErr: true},
+		{name: "message used by another operation", custom: Naming{Get: NamingOp{Message: "Update%s"}}, wantErr: true},
+		{name: "rpc used by another operation", scheme: NamingSchemeAip, custom: Naming{Export: NamingOp{Rpc: "List%p"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewNaming(tt.scheme, tt.custom); (nil != err) != tt.wantErr {
+				t.Errorf("NewNaming() error = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package parser
+
+// Rpc represents a protocol buffer service rpc function.
+type Rpc struct {
+	Name     string
+	Request  string
+	Response string
+	Comment  string
+	// Stream reports whether the response is server streamed
+	Stream bool
+}
+
+// GenRpcs gen the rpc functions of the message
+func (m *Message) GenRpcs() []Rpc {
+	var rpcs []Rpc
+	if m.HasOp(OpCreate) {
+		rpcs = append(rpcs, m.newRpc(m.Naming.Create, m.Catalog.T("rpc.create", m.Comment)))
+	}
+	if m.HasOp(OpUpdate) {
+		rpcs = append(rpcs, m.newRpc(m.Naming.Update, m.Catalog.T("rpc.update", m.Comment)))
+	}
+	if m.HasOp(OpDelete) {
+		rpcs = append(rpcs, m.newRpc(m.Naming.Delete, m.Catalog.T("rpc.delete", m.Comment)))
+	}
+	if m.HasOp(OpGet) {
+		rpcs = append(rpcs, m.newRpc(m.Naming.Get, m.Catalog.T("rpc.get", m.Comment)))
+	}
+	if m.HasOp(OpList) {
+		rpcs = append(rpcs, m.newRpc(m.Naming.List, m.Catalog.T("rpc.list", m.Comment)))
+	}
+	if m.Batch && m.HasOp(OpCreate) {
+		rpcs = append(rpcs, m.newRpc(m.Naming.BatchCreate, m.Catalog.T("rpc.batch_create", m.Comment)))
+	}
+	if m.Batch && m.HasOp(OpUpdate) {
+		rpcs = append(rpcs, m.newRpc(m.Naming.BatchUpdate, m.Catalog.T("rpc.batch_update", m.Comment)))
+	}
+	if m.Batch && m.HasOp(OpDelete) {
+		rpcs = append(rpcs, m.newRpc(m.Naming.BatchDelete, m.Catalog.T("rpc.batch_delete", m.Comment)))
+	}
+	if m.Batch && m.HasOp(OpGet) {
+		rpcs = append(rpcs, m.newRpc(m.Naming.BatchGet, m.Catalog.T("rpc.batch_get", m.Comment)))
+	}
+	if m.Stream && m.HasOp(OpList) {
+		// the rows are streamed as the default message
+		rpc := m.newRpc(m.Naming.Export, m.Catalog.T("rpc.export", m.Comment))
+		rpc.Response = m.Name
+		rpc.Stream = true
+		rpcs = append(rpcs, rpc)
+	}
+
+	return rpcs
+}
+
+// newRpc returns the rpc function of the naming operation.
+func (m *Message) newRpc(op NamingOp, comment string) Rpc {
+	return Rpc{
+		Name:     op.RpcName(m.Name, m.Plural),
+		Request:  m.Naming.Request(op, m.Name, m.Plural),
+		Response: m.Naming.Response(op, m.Name, m.Plural),
+		Comment:  comment,
+	}
+}
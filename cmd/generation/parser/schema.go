@@ -6,10 +6,12 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"text/template"
 
 	"github.com/chuckpreslar/inflect"
 	"github.com/serenize/snaker"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
 )
 
 type Schema struct {
@@ -20,15 +22,110 @@ type Schema struct {
 	Imports     sort.StringSlice
 	Messages    []*Message
 	Enums       []*Enum
+	// VersionColumn is the optimistic locking column passed on to every message.
+	VersionColumn string
+	// UpdateStyle is the update request style passed on to every message.
+	UpdateStyle string
+	// RespStyle is the add and update response style passed on to every message.
+	RespStyle string
+	// Pagination is the list request pagination passed on to every message.
+	Pagination string
+	// OrderBy is the list request ordering passed on to every message.
+	OrderBy string
+	// FilterStyle is the filter message style passed on to every message.
+	FilterStyle string
+	// Batch enables the batch rpc functions of every message.
+	Batch bool
+	// Stream enables the server streaming export rpc function of every message.
+	Stream bool
+	// Ops is the rpc operations of every message, nil generates every operation and empty none.
+	Ops []string
+	// TableOps overrides Ops per table name. the per table options are keyed by table name or by
+	// schema qualified table name, e.g. audit.user, which takes precedence.
+	TableOps map[string][]string
+	// TableIgnoreColumns are the columns ignored per table name.
+	TableIgnoreColumns map[string][]string
+	// TableNames overrides the message name per table name.
+	TableNames map[string]string
+	// ColumnNames overrides the field names per table and column name.
+	ColumnNames map[string]map[string]string
+	// Types overrides the protobuf type of a database data type.
+	Types map[string]string
+	// SchemaPrefix prefixes the message and enum names with the table schema, e.g. when tables of
+	// several postgres schemas share a name.
+	SchemaPrefix bool
+	// Template renders the schema, nil renders the embedded default layout.
+	Template *template.Template
+	// Naming is the naming convention passed on to every message, empty uses the goctl naming.
+	Naming Naming
+	// Singularize names the messages after the singular table name, the table name is the plural.
+	Singularize bool
+	// Singulars overrides the singular of irregular table names.
+	Singulars map[string]string
+	// TrimPrefixes are the table name prefixes stripped from message and enum names.
+	TrimPrefixes []string
+	// TrimSuffixes are the table name suffixes stripped from message and enum names.
+	TrimSuffixes []string
+	// Catalog is the text of the generated comments passed on to every message, nil uses zh.
+	Catalog Catalog
+	// Lock keeps the field numbers of the generated messages, passed on to every message.
+	Lock *Lock
 }
 
 func NewSchema(syntax string, serviceName string, goPackage string, Package string) *Schema {
 	return &Schema{Syntax: syntax, ServiceName: serviceName, GoPackage: goPackage, Package: Package}
 }
 
+// Validate returns an error if an option of the schema is not supported.
+func (s *Schema) Validate() error {
+	switch s.UpdateStyle {
+	case "", UpdateStyleOptional, UpdateStyleFieldMask:
+	default:
+		return fmt.Errorf("update style `%s` not supported. %s | %s", s.UpdateStyle, UpdateStyleOptional, UpdateStyleFieldMask)
+	}
+	switch s.RespStyle {
+	case "", RespStyleEmpty, RespStyleId, RespStyleEntity:
+	default:
+		return fmt.Errorf("resp style `%s` not supported. %s | %s | %s", s.RespStyle, RespStyleEmpty, RespStyleId, RespStyleEntity)
+	}
+	switch s.Pagination {
+	case "", PaginationOffset, PaginationCursor, PaginationAip158:
+	default:
+		return fmt.Errorf("pagination `%s` not supported. %s | %s | %s", s.Pagination, PaginationOffset, PaginationCursor, PaginationAip158)
+	}
+	switch s.OrderBy {
+	case "", OrderByNone, OrderByString, OrderByMessage:
+	default:
+		return fmt.Errorf("order by `%s` not supported. %s | %s | %s", s.OrderBy, OrderByNone, OrderByString, OrderByMessage)
+	}
+	switch s.FilterStyle {
+	case "", FilterStyleEqual, FilterStyleOperator:
+	default:
+		return fmt.Errorf("filter style `%s` not supported. %s | %s", s.FilterStyle, FilterStyleEqual, FilterStyleOperator)
+	}
+	if err := checkOps(s.Ops); nil != err {
+		return err
+	}
+	for t, ops := range s.TableOps {
+		if err := checkOps(ops); nil != err {
+			return fmt.Errorf("table `%s`: %w", t, err)
+		}
+	}
+
+	return nil
+}
+
 // TypesFromColumns creates the appropriate schema properties from a collection of column types.
 func (s *Schema) TypesFromColumns(cols []Column, ignoreTables, ignoreColumns []string, fieldStyle string) error {
+	if err := s.Validate(); nil != err {
+		return err
+	}
+	if s.Naming == (Naming{}) {
+		s.Naming = NamingGoctl
+	}
+
 	messageMap := map[string]*Message{}
+	tableMap := map[string]string{}
 	ignoreMap := map[string]bool{}
 	ignoreColumnMap := map[string]bool{}
 	for _, ig := range ignoreTables {
@@ -39,19 +136,52 @@ func (s *Schema) TypesFromColumns(cols []Column, ignoreTables, ignoreColumns []s
 	}
 
 	for _, c := range cols {
-		if _, ok := ignoreMap[c.TableName]; ok {
+		if ignoreMap[c.TableName] || "" != c.TableSchema && ignoreMap[c.TableSchema+"."+c.TableName] {
 			continue
 		}
 		if _, ok := ignoreColumnMap[c.ColumnName]; ok {
 			continue
 		}
+		if columns, _ := tableOption(s.TableIgnoreColumns, c.TableSchema, c.TableName); slices.Contains(columns, c.ColumnName) {
+			continue
+		}
 
-		messageName := snaker.SnakeToCamel(c.TableName)
+		table := c.TableName
+		if "" != c.TableSchema {
+			table = c.TableSchema + "." + c.TableName
+		}
+		messageName, plural := s.messageName(c.TableSchema, c.TableName)
+		if t, ok := tableMap[messageName]; ok && t != table {
+			return fmt.Errorf("tables `%s` and `%s` are both generated as message `%s`", t, table, messageName)
+		}
+		tableMap[messageName] = table
 
 		msg, ok := messageMap[messageName]
 		if !ok {
-			messageMap[messageName] = &Message{Name: messageName, Comment: c.TableComment, Style: fieldStyle}
+			messageMap[messageName] = &Message{
+				Name:          messageName,
+				Comment:       c.TableComment,
+				Style:         fieldStyle,
+				VersionColumn: s.VersionColumn,
+				UpdateStyle:   s.UpdateStyle,
+				RespStyle:     s.RespStyle,
+				Pagination:    s.Pagination,
+				OrderBy:       s.OrderBy,
+				FilterStyle:   s.FilterStyle,
+				Batch:         s.Batch,
+				Stream:        s.Stream,
+				Ops:           s.Ops,
+				Naming:        s.Naming,
+				Plural:        plural,
+				Catalog:       s.Catalog,
+				Lock:          s.Lock,
+			}
+			if ops, ok := tableOption(s.TableOps, c.TableSchema, c.TableName); ok {
+				messageMap[messageName].Ops = ops
+			}
 			msg = messageMap[messageName]
+			// the messages follow the column order
+			s.Messages = append(s.Messages, msg)
 		}
 
 		err := s.parseColumn(msg, c)
@@ -60,18 +190,170 @@ func (s *Schema) TypesFromColumns(cols []Column, ignoreTables, ignoreColumns []s
 		}
 	}
 
-	for _, v := range messageMap {
-		s.Messages = append(s.Messages, v)
+	// the update request of a message masks its fields
+	for _, m := range s.Messages {
+		if m.UpdateStyle == UpdateStyleFieldMask && m.HasOp(OpUpdate) {
+			s.AppendImport("google/protobuf/field_mask.proto")
+		}
+	}
+
+	return nil
+}
+
+// messageName returns the message name of a table and its plural.
+func (s *Schema) messageName(schema, table string) (string, string) {
+	if name, ok := tableOption(s.TableNames, schema, table); ok {
+		return name, snaker.SnakeToCamel(pluralize(snaker.CamelToSnake(name)))
+	}
+
+	prefix := s.schemaPrefix(schema)
+	trimmed := s.trimTableName(table)
+	if !s.Singularize {
+		return prefix + snaker.SnakeToCamel(trimmed), prefix + snaker.SnakeToCamel(pluralize(trimmed))
+	}
+
+	singular, ok := tableOption(s.Singulars, schema, table)
+	if !ok {
+		singular = singularize(trimmed)
+	}
+
+	return prefix + snaker.SnakeToCamel(singular), prefix + snaker.SnakeToCamel(pluralize(singular))
+}
+
+// uncountables are the words inflect takes for plurals which are their own plural, e.g. the sms table.
+var uncountables = []string{"sms"}
+
+// singularEndings are the endings of singular words inflect takes for plurals, e.g. status, address
+// or bus.
+var singularEndings = []string{"us", "ss", "is"}
+
+// isPlural reports whether a word is plural, e.g. users or people.
+func isPlural(word string) bool {
+	for _, ending := range singularEndings {
+		if strings.HasSuffix(word, ending) {
+			return false
+		}
+	}
+	singular := inflect.Singularize(word)
+
+	return singular != word && inflect.Pluralize(singular) == word
+}
+
+// pluralize returns the plural of a snake case name by its last word, the name itself when the
+// word is already plural.
+func pluralize(name string) string {
+	i := strings.LastIndex(name, "_") + 1
+	word := name[i:]
+	if slices.Contains(uncountables, word) || isPlural(word) {
+		return name
+	}
+
+	return name[:i] + inflect.Pluralize(word)
+}
+
+// singularize returns the singular of a snake case name by its last word, the name itself when the
+// word is already singular.
+func singularize(name string) string {
+	i := strings.LastIndex(name, "_") + 1
+	word := name[i:]
+	if slices.Contains(uncountables, word) || !isPlural(word) {
+		return name
+	}
+
+	return name[:i] + inflect.Singularize(word)
+}
+
+// tableOption returns the option of a table in a per table option map, looked up by the table
+// name qualified with its schema first, e.g. audit.user, then by the table name.
+func tableOption[V any](options map[string]V, schema, table string) (V, bool) {
+	if "" != schema {
+		if v, ok := options[schema+"."+table]; ok {
+			return v, true
+		}
+	}
+	v, ok := options[table]
+
+	return v, ok
+}
+
+// schemaPrefix returns the message and enum name prefix of a table schema, empty without
+// SchemaPrefix.
+func (s *Schema) schemaPrefix(schema string) string {
+	if !s.SchemaPrefix {
+		return ""
+	}
+
+	return snaker.SnakeToCamel(schema)
+}
+
+// trimTableName strips the first matching prefix and suffix from a table name. a table name
+// that would be left empty is kept.
+func (s *Schema) trimTableName(table string) string {
+	for _, prefix := range s.TrimPrefixes {
+		if prefix != "" && strings.HasPrefix(table, prefix) && len(table) > len(prefix) {
+			table = strings.TrimPrefix(table, prefix)
+			break
+		}
+	}
+	for _, suffix := range s.TrimSuffixes {
+		if suffix != "" && strings.HasSuffix(table, suffix) && len(table) > len(suffix) {
+			table = strings.TrimSuffix(table, suffix)
+			break
+		}
+	}
+
+	return table
+}
+
+// checkOps returns an error if an rpc operation is not supported.
+func checkOps(ops []string) error {
+	for _, op := range ops {
+		if !slices.Contains(Ops, op) {
+			return fmt.Errorf("op `%s` not supported. %s", op, strings.Join(Ops, " | "))
+		}
 	}
 
 	return nil
 }
 
+// wellKnownImports are the imports of the well known types a database data type may be overridden with.
+var wellKnownImports = map[string]string{
+	"google.protobuf.Any":         "google/protobuf/any.proto",
+	"google.protobuf.Duration":    "google/protobuf/duration.proto",
+	"google.protobuf.Struct":      "google/protobuf/struct.proto",
+	"google.protobuf.Value":       "google/protobuf/struct.proto",
+	"google.protobuf.ListValue":   "google/protobuf/struct.proto",
+	"google.protobuf.Timestamp":   "google/protobuf/timestamp.proto",
+	"google.protobuf.StringValue": "google/protobuf/wrappers.proto",
+	"google.protobuf.Int64Value":  "google/protobuf/wrappers.proto",
+	"google.protobuf.DoubleValue": "google/protobuf/wrappers.proto",
+	"google.protobuf.BoolValue":   "google/protobuf/wrappers.proto",
+	"google.protobuf.BytesValue":  "google/protobuf/wrappers.proto",
+}
+
+// AppendImport adds an import statement to the schema if it is not already present.
+func (s *Schema) AppendImport(imports string) {
+	for _, i := range s.Imports {
+		if i == imports {
+			return
+		}
+	}
+
+	s.Imports = append(s.Imports, imports)
+}
+
 // parseColumn parses a column and inserts the relevant fields in the Message. If an enumerated type is encountered, an Enum will
 // be added to the Schema. Returns an error if an incompatible protobuf data type cannot be found for the database column type.
 func (s *Schema) parseColumn(msg *Message, col Column) error {
 	typ := strings.ToLower(col.DataType)
 	var fieldType string
+	if custom, ok := s.Types[typ]; ok {
+		// the overridden type matches no case below, an enum column gens no enum
+		fieldType, typ = custom, ""
+		if imp, ok := wellKnownImports[custom]; ok {
+			s.AppendImport(imp)
+		}
+	}
 
 	switch typ {
 	case "char", "varchar", "text", "longtext", "mediumtext", "tinytext":
@@ -84,7 +366,10 @@ func (s *Schema) parseColumn(msg *Message, col Column) error {
 			return "," == cs || "'" == cs
 		})
 
-		enumName := inflect.Singularize(snaker.SnakeToCamel(col.TableName)) + snaker.SnakeToCamel(col.ColumnName)
+		enumName := s.schemaPrefix(col.TableSchema) + snaker.SnakeToCamel(singularize(s.trimTableName(col.TableName))) + snaker.SnakeToCamel(col.ColumnName)
+		if name, ok := tableOption(s.TableNames, col.TableSchema, col.TableName); ok {
+			enumName = name + snaker.SnakeToCamel(col.ColumnName)
+		}
 		enum, err := newEnumFromStrings(enumName, col.ColumnComment, enums)
 		if nil != err {
 			return err
@@ -117,7 +402,17 @@ func (s *Schema) parseColumn(msg *Message, col Column) error {
 		logrus.Warning(fmt.Errorf("no compatible protobuf type found for `%s`. column: `%s`.`%s`. default set column 'string'", col.DataType, col.TableName, col.ColumnName).Error())
 	}
 
-	field := NewMessageField(fieldType, col.ColumnName, len(msg.Fields)+1, col.ColumnComment)
+	name := col.ColumnName
+	names, _ := tableOption(s.ColumnNames, col.TableSchema, col.TableName)
+	if n, ok := names[col.ColumnName]; ok {
+		name = n
+	}
+
+	field := NewMessageField(fieldType, name, len(msg.Fields)+1, col.ColumnComment)
+	field.Indexed = col.ColumnKey != ""
+	field.Primary = col.ColumnKey == "PRI"
+	field.Column = col.ColumnName
+	field.Nullable = col.IsNullable == "YES"
 
 	err := msg.AppendField(field)
 	if nil != err {
@@ -127,65 +422,43 @@ func (s *Schema) parseColumn(msg *Message, col Column) error {
 	return nil
 }
 
-// String returns a string representation of a Schema.
-func (s *Schema) String() string {
-	buf := new(bytes.Buffer)
-	buf.WriteString(fmt.Sprintf("syntax = \"%s\";\n", s.Syntax))
-	buf.WriteString("\n")
-	buf.WriteString(fmt.Sprintf("option go_package =\"%s\";\n", s.GoPackage))
-	buf.WriteString("\n")
-	buf.WriteString(fmt.Sprintf("package %s;\n", s.Package))
-
-	buf.WriteString("\n")
-	buf.WriteString("// ------------------------------------ \n")
-	buf.WriteString("// Messages\n")
-	buf.WriteString("// ------------------------------------ \n\n")
-
+// GenEnums gen the column enums followed by the order by enums of every message, numbered by the lock
+func (s *Schema) GenEnums() []*Enum {
+	enums := append([]*Enum{}, s.Enums...)
 	for _, m := range s.Messages {
-		buf.WriteString("//--------------------------------" + m.Comment + "--------------------------------")
-		buf.WriteString("\n\n")
-		m.GenDefaultMessage(buf)
-		m.GenDefaultFilterMessage(buf)
-		m.GenRpcAddReqRespMessage(buf)
-		m.GenRpcUpdateReqMessage(buf)
-		m.GenRpcDelReqMessage(buf)
-		m.GenRpcGetByIdReqMessage(buf)
-		m.GenRpcSearchReqMessage(buf)
+		if enum := m.GenOrderByEnum(); nil != enum {
+			s.Lock.applyEnum(enum)
+			enums = append(enums, enum)
+		}
 	}
 
-	buf.WriteString("\n")
-
-	if len(s.Enums) > 0 {
-		buf.WriteString("// ------------------------------------ \n")
-		buf.WriteString("// Enums\n")
-		buf.WriteString("// ------------------------------------ \n\n")
+	return enums
+}
 
-		for _, e := range s.Enums {
-			buf.WriteString(fmt.Sprintf("%s\n", e))
+// Render executes the schema template. the embedded default layout is used when no template is set.
+func (s *Schema) Render() (string, error) {
+	tpl := s.Template
+	if nil == tpl {
+		var err error
+		if tpl, err = NewTemplate("", ""); nil != err {
+			return "", err
 		}
 	}
 
-	buf.WriteString("\n")
-	buf.WriteString("// ------------------------------------ \n")
-	buf.WriteString("// Rpc Func\n")
-	buf.WriteString("// ------------------------------------ \n\n")
+	buf := new(bytes.Buffer)
+	if err := tpl.ExecuteTemplate(buf, defaultTemplateName, s); nil != err {
+		return "", err
+	}
 
-	funcTpl := "service " + s.ServiceName + "{ \n\n"
-	for _, m := range s.Messages {
-		funcTpl += "\t //-----------------------" + m.Comment + "----------------------- \n"
-		funcTpl += "\n\t // 创建" + m.Comment + "\n"
-		funcTpl += "\t rpc Insert" + m.Name + "(Add" + m.Name + "Req) returns (Add" + m.Name + "Resp); \n"
-		funcTpl += "\n\t // 更新" + m.Comment + "\n"
-		funcTpl += "\t rpc Update" + m.Name + "(Update" + m.Name + "Req) returns (Update" + m.Name + "Resp); \n"
-		funcTpl += "\n\t // 根据 " + m.Comment + " id 删除\n"
-		funcTpl += "\t rpc Delete" + m.Name + "(Del" + m.Name + "Req) returns (Del" + m.Name + "Resp); \n"
-		funcTpl += "\n\t // 根据 " + m.Comment + " id 获取详情\n"
-		funcTpl += "\t rpc Select" + m.Name + "ById(Select" + m.Name + "ByIdReq) returns (Select" + m.Name + "ByIdResp); \n"
-		funcTpl += "\n\t // " + m.Comment + " 列表\n"
-		funcTpl += "\t rpc Select" + m.Name + "List(Select" + m.Name + "ListReq) returns (Select" + m.Name + "ListResp); \n"
-	}
-	funcTpl = funcTpl + "\n}"
-	buf.WriteString(funcTpl)
-
-	return buf.String()
+	return buf.String(), nil
+}
+
+// String returns a string representation of a Schema.
+func (s *Schema) String() string {
+	out, err := s.Render()
+	if nil != err {
+		return err.Error()
+	}
+
+	return out
 }
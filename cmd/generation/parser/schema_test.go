@@ -0,0 +1,34 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestMessageName(t *testing.T) {
+	tests := []struct {
+		table       string
+		singularize bool
+		name        string
+		plural      string
+	}{
+		{table: "users", name: "Users", plural: "Users"},
+		{table: "user", name: "User", plural: "Users"},
+		{table: "people", name: "People", plural: "People"},
+		{table: "sys_config", name: "SysConfig", plural: "SysConfigs"},
+		{table: "users", singularize: true, name: "User", plural: "Users"},
+		{table: "people", singularize: true, name: "Person", plural: "People"},
+		{table: "sys_config", singularize: true, name: "SysConfig", plural: "SysConfigs"},
+		{table: "status", singularize: true, name: "Status", plural: "Statuses"},
+		{table: "address", singularize: true, name: "Address", plural: "Addresses"},
+		{table: "bus", singularize: true, name: "Bus", plural: "Buses"},
+		{table: "sms", singularize: true, name: "Sms", plural: "Sms"},
+		{table: "order_items", singularize: true, name: "OrderItem", plural: "OrderItems"},
+	}
+	for _, tt := range tests {
+		s := &Schema{Singularize: tt.singularize}
+		name, plural := s.messageName("", tt.table)
+		if name != tt.name || plural != tt.plural {
+			t.Errorf("messageName(%q) singularize %t = %s, %s, want %s, %s", tt.table, tt.singularize, name, plural, tt.name, tt.plural)
+		}
+	}
+}
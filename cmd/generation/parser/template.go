@@ -0,0 +1,50 @@
+package parser
+
+import (
+	_ "embed"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// defaultTemplateName is the name of the template rendering a Schema. the "message" and "enum"
+// templates it calls may be overridden on their own.
+const defaultTemplateName = "schema.tpl"
+
+//go:embed template/schema.tpl
+var defaultTemplate string
+
+// templateFuncs are the functions available to the templates.
+var templateFuncs = template.FuncMap{
+	"comment": Comment,
+	"oneline": OneLine,
+}
+
+// NewTemplate returns the schema template. the embedded default layout is overridden by the
+// *.tpl files of dir, then by file which replaces the schema template itself.
+func NewTemplate(file, dir string) (*template.Template, error) {
+	tpl, err := template.New(defaultTemplateName).Funcs(templateFuncs).Parse(defaultTemplate)
+	if nil != err {
+		return nil, err
+	}
+
+	if dir != "" {
+		if tpl, err = tpl.ParseGlob(filepath.Join(dir, "*.tpl")); nil != err {
+			return nil, errors.Wrapf(err, "parse template dir %s", dir)
+		}
+	}
+
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if nil != err {
+			return nil, errors.Wrapf(err, "read template %s", file)
+		}
+		if _, err = tpl.New(defaultTemplateName).Parse(string(data)); nil != err {
+			return nil, errors.Wrapf(err, "parse template %s", file)
+		}
+	}
+
+	return tpl, nil
+}
@@ -0,0 +1,46 @@
+syntax = "{{.Syntax}}";
+
+{{if .Imports}}{{range .Imports}}import "{{.}}";
+{{end}}
+{{end}}option go_package ="{{.GoPackage}}";
+
+package {{.Package}};
+
+// ------------------------------------ 
+// Messages
+// ------------------------------------ 
+
+{{if eq .FilterStyle "operator"}}//--------------------------------Filter--------------------------------
+
+{{range .GenFilterOperatorMessages}}{{template "message" .}}
+{{end}}{{end}}{{range .Messages}}//--------------------------------{{oneline .Comment}}--------------------------------
+
+{{range .GenMessages}}{{template "message" .}}
+{{end}}{{end}}
+{{if .GenEnums}}// ------------------------------------ 
+// Enums
+// ------------------------------------ 
+
+{{range .GenEnums}}{{template "enum" .}}
+{{end}}{{end}}
+// ------------------------------------ 
+// Rpc Func
+// ------------------------------------ 
+
+service {{.ServiceName}}{ 
+
+{{range .Messages}}	 //-----------------------{{oneline .Comment}}----------------------- 
+{{range .GenRpcs}}
+{{comment "\t " .Comment}}	 rpc {{.Name}}({{.Request}}) returns ({{if .Stream}}stream {{end}}{{.Response}}); 
+{{end}}{{end}}
+}
+{{- define "message"}}{{comment "" .Comment}}message {{.Name}} {
+{{with .ReservedTags}}  reserved {{.}};
+{{end}}{{with .ReservedNames}}  reserved {{.}};
+{{end}}{{range .Fields}}{{comment "  " .Comment}}  {{.}};
+{{end}}}
+{{end}}
+{{- define "enum"}}{{comment "" .Comment}}enum {{.Name}} {
+{{range .Fields}}  {{.}};
+{{end}}}
+{{end -}}
@@ -21,5 +21,6 @@ func Execute() {
 
 func init() {
 	rootCmd.AddCommand(generation.GenCmd)
+	rootCmd.AddCommand(generation.CheckCmd)
 
 }